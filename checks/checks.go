@@ -0,0 +1,42 @@
+// Package checks provides health checks that ContainerPilot runs natively
+// within its own process, rather than by forking a health check executable.
+package checks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joyent/containerpilot/events"
+	log "github.com/sirupsen/logrus"
+)
+
+// run executes the check function asynchronously and publishes its result
+// onto the EventBus exactly as a health check exec would, so that Jobs can
+// treat all their health checks the same way.
+func run(pctx context.Context, bus *events.EventBus, name string,
+	timeout time.Duration, check func(context.Context) error) {
+
+	go func() {
+		ctx, cancel := getContext(pctx, timeout)
+		defer cancel()
+		log.Debugf("%s.Run start", name)
+		defer log.Debugf("%s.Run end", name)
+		if err := check(ctx); err != nil {
+			log.Errorf("%s failed: %v", name, err)
+			bus.Publish(events.Event{Code: events.ExitFailed, Source: name})
+			bus.Publish(events.Event{Code: events.Error,
+				Source: fmt.Errorf("%s: %s", name, err).Error()})
+			return
+		}
+		log.Debugf("%s passed", name)
+		bus.Publish(events.Event{Code: events.ExitSuccess, Source: name})
+	}()
+}
+
+func getContext(pctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(pctx, timeout)
+	}
+	return context.WithCancel(pctx)
+}
@@ -0,0 +1,128 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+// GRPCConfig configures a health check that uses the standard gRPC
+// health checking protocol (grpc.health.v1.Health/Check)
+type GRPCConfig struct {
+	Address string         `mapstructure:"address"` // host:port
+	Service string         `mapstructure:"service"` // optional
+	TLS     *GRPCTLSConfig `mapstructure:"tls"`     // optional
+}
+
+// GRPCTLSConfig configures the TLS connection for a gRPC health check.
+// If it's omitted from the GRPCConfig the connection will be plaintext.
+type GRPCTLSConfig struct {
+	CAFile     string `mapstructure:"cafile"`
+	ClientCert string `mapstructure:"clientcert"`
+	ClientKey  string `mapstructure:"clientkey"`
+	ServerName string `mapstructure:"servername"`
+	SkipVerify bool   `mapstructure:"skipverify"`
+}
+
+// GRPCCheck is a health check that passes if the gRPC health service
+// at its address reports its service as SERVING.
+type GRPCCheck struct {
+	Name    string
+	Address string
+	Service string
+	Timeout time.Duration
+
+	dialOpts []grpc.DialOption
+}
+
+// NewGRPCCheck validates a GRPCConfig and creates a GRPCCheck from it
+func NewGRPCCheck(name string, cfg *GRPCConfig, timeout time.Duration) (*GRPCCheck, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("'address' must not be blank")
+	}
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("could not parse 'address': %v", err)
+	}
+	transport := grpc.WithInsecure()
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.toTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	return &GRPCCheck{
+		Name:     name,
+		Address:  cfg.Address,
+		Service:  cfg.Service,
+		Timeout:  timeout,
+		dialOpts: []grpc.DialOption{transport},
+	}, nil
+}
+
+func (cfg *GRPCTLSConfig) toTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.SkipVerify,
+	}
+	if cfg.CAFile != "" {
+		caCert, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read 'tls.cafile': %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in 'tls.cafile' %s",
+				cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not load 'tls.clientcert' and 'tls.clientkey': %v",
+				err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Run calls the gRPC health service asynchronously and publishes the
+// result of the check to the EventBus.
+func (check *GRPCCheck) Run(pctx context.Context, bus *events.EventBus) {
+	run(pctx, bus, check.Name, check.Timeout, check.check)
+}
+
+func (check *GRPCCheck) check(ctx context.Context) error {
+	conn, err := grpc.DialContext(ctx, check.Address, check.dialOpts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx,
+		&healthpb.HealthCheckRequest{Service: check.Service})
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service status: %s", resp.Status)
+	}
+	return nil
+}
+
+// String implements the stdlib fmt.Stringer interface for pretty-printing
+func (check *GRPCCheck) String() string {
+	return "checks.GRPCCheck[" + check.Name + "]"
+}
@@ -0,0 +1,71 @@
+package checks
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+func TestGRPCCheckConfigError(t *testing.T) {
+	_, err := NewGRPCCheck("check.myjob", &GRPCConfig{}, time.Second)
+	assert.Equal(t, "'address' must not be blank", err.Error())
+
+	_, err = NewGRPCCheck("check.myjob", &GRPCConfig{Address: "localhost"}, time.Second)
+	assert.Equal(t,
+		"could not parse 'address': address localhost: missing port in address",
+		err.Error())
+
+	_, err = NewGRPCCheck("check.myjob", &GRPCConfig{
+		Address: "localhost:9000",
+		TLS:     &GRPCTLSConfig{CAFile: "./testdata/nonexistent.pem"},
+	}, time.Second)
+	assert.Equal(t,
+		"could not read 'tls.cafile': open ./testdata/nonexistent.pem: no such file or directory",
+		err.Error())
+}
+
+func TestGRPCCheckRun(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(ln)
+	defer server.Stop()
+
+	healthServer.SetServingStatus("serving", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("notServing", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	testFunc := func(t *testing.T, service string) map[events.Event]int {
+		check, err := NewGRPCCheck(t.Name(), &GRPCConfig{
+			Address: ln.Addr().String(),
+			Service: service,
+		}, 200*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtestCheck(check)
+	}
+
+	t.Run("serving", func(t *testing.T) {
+		got := testFunc(t, "serving")
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: t.Name()}])
+	})
+	t.Run("not serving", func(t *testing.T) {
+		got := testFunc(t, "notServing")
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitFailed, Source: t.Name()}])
+	})
+	t.Run("unknown service", func(t *testing.T) {
+		got := testFunc(t, "unknown")
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitFailed, Source: t.Name()}])
+	})
+}
@@ -0,0 +1,153 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+// we don't want a misbehaving endpoint to make us buffer an unbounded
+// response body just to match it against the 'body' pattern
+const maxBodySize = 64 * 1024
+
+// HTTPConfig configures a health check that makes an HTTP GET request
+type HTTPConfig struct {
+	URL     string            `mapstructure:"url"`
+	Status  string            `mapstructure:"status"` // ex. "200" or "200-299"
+	Body    string            `mapstructure:"body"`   // regex
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+// HTTPCheck is a health check that passes if its URL responds with a
+// status code in the expected range and, optionally, a response body
+// that matches a regular expression.
+type HTTPCheck struct {
+	Name    string
+	URL     string
+	Timeout time.Duration
+
+	headers   map[string]string
+	minStatus int
+	maxStatus int
+	body      *regexp.Regexp
+	client    *http.Client
+}
+
+// NewHTTPCheck validates an HTTPConfig and creates an HTTPCheck from it
+func NewHTTPCheck(name string, cfg *HTTPConfig, timeout time.Duration) (*HTTPCheck, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("'url' must not be blank")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse 'url': %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("'url' scheme must be 'http' or 'https', got '%s'",
+			u.Scheme)
+	}
+	minStatus, maxStatus, err := parseStatusRange(cfg.Status)
+	if err != nil {
+		return nil, err
+	}
+	check := &HTTPCheck{
+		Name:      name,
+		URL:       cfg.URL,
+		Timeout:   timeout,
+		headers:   cfg.Headers,
+		minStatus: minStatus,
+		maxStatus: maxStatus,
+		client:    cleanhttp.DefaultClient(),
+	}
+	if cfg.Body != "" {
+		body, err := regexp.Compile(cfg.Body)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse 'body': %v", err)
+		}
+		check.body = body
+	}
+	return check, nil
+}
+
+// parseStatusRange parses the 'status' field, which is either a single
+// status code or an inclusive range of codes. Defaults to any 2xx.
+func parseStatusRange(raw string) (int, int, error) {
+	if raw == "" {
+		return 200, 299, nil
+	}
+	bounds := strings.SplitN(raw, "-", 2)
+	min, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid 'status' '%s'", raw)
+	}
+	max := min
+	if len(bounds) == 2 {
+		max, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid 'status' '%s'", raw)
+		}
+	}
+	if min < 100 || max > 599 || min > max {
+		return 0, 0, fmt.Errorf("invalid 'status' '%s': must be between 100 and 599",
+			raw)
+	}
+	return min, max, nil
+}
+
+// Run makes the HTTP request asynchronously and publishes the result
+// of the check to the EventBus.
+func (check *HTTPCheck) Run(pctx context.Context, bus *events.EventBus) {
+	run(pctx, bus, check.Name, check.Timeout, check.check)
+}
+
+func (check *HTTPCheck) check(ctx context.Context) error {
+	req, err := http.NewRequest(http.MethodGet, check.URL, nil)
+	if err != nil {
+		return err
+	}
+	for key, val := range check.headers {
+		if strings.EqualFold(key, "host") {
+			req.Host = val
+			continue
+		}
+		req.Header.Set(key, val)
+	}
+	resp, err := check.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < check.minStatus || resp.StatusCode > check.maxStatus {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxBodySize))
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if check.body == nil {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxBodySize))
+		return nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if !check.body.Match(body) {
+		return fmt.Errorf("response body did not match '%s'", check.body)
+	}
+	return nil
+}
+
+// String implements the stdlib fmt.Stringer interface for pretty-printing
+func (check *HTTPCheck) String() string {
+	return "checks.HTTPCheck[" + check.Name + "]"
+}
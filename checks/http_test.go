@@ -0,0 +1,141 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+func TestHTTPCheckConfigError(t *testing.T) {
+	expectErr := func(cfg *HTTPConfig, errMsg string) {
+		_, err := NewHTTPCheck("check.myjob", cfg, time.Second)
+		if assert.Error(t, err) {
+			assert.Equal(t, errMsg, err.Error())
+		}
+	}
+	expectErr(&HTTPConfig{}, "'url' must not be blank")
+	expectErr(&HTTPConfig{URL: "tcp://localhost:80"},
+		"'url' scheme must be 'http' or 'https', got 'tcp'")
+	expectErr(&HTTPConfig{URL: "http://localhost", Status: "ok"},
+		"invalid 'status' 'ok'")
+	expectErr(&HTTPConfig{URL: "http://localhost", Status: "299-200"},
+		"invalid 'status' '299-200': must be between 100 and 599")
+	expectErr(&HTTPConfig{URL: "http://localhost", Body: "("},
+		"could not parse 'body': error parsing regexp: missing closing ): `(`")
+}
+
+func TestHTTPCheckStatusRange(t *testing.T) {
+	for _, test := range []struct {
+		raw      string
+		min, max int
+	}{
+		{"", 200, 299},
+		{"204", 204, 204},
+		{"200-399", 200, 399},
+		{" 200 - 299 ", 200, 299},
+	} {
+		min, max, err := parseStatusRange(test.raw)
+		assert.Nil(t, err)
+		assert.Equal(t, test.min, min, "min status for '%s'", test.raw)
+		assert.Equal(t, test.max, max, "max status for '%s'", test.raw)
+	}
+}
+
+func TestHTTPCheckRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/ok":
+				fmt.Fprint(w, `{"status": "ok"}`)
+			case "/header":
+				if r.Header.Get("X-Check") != "yes" {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			case "/slow":
+				time.Sleep(200 * time.Millisecond)
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+	defer server.Close()
+
+	testFunc := func(t *testing.T, cfg *HTTPConfig) map[events.Event]int {
+		cfg.URL = server.URL + cfg.URL
+		check, err := NewHTTPCheck(t.Name(), cfg, 100*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtestCheck(check)
+	}
+
+	t.Run("passing", func(t *testing.T) {
+		got := testFunc(t, &HTTPConfig{URL: "/ok"})
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: t.Name()}])
+	})
+	t.Run("body match", func(t *testing.T) {
+		got := testFunc(t, &HTTPConfig{URL: "/ok", Body: `"status":\s*"ok"`})
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: t.Name()}])
+	})
+	t.Run("body mismatch", func(t *testing.T) {
+		got := testFunc(t, &HTTPConfig{URL: "/ok", Body: "fail"})
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitFailed, Source: t.Name()}])
+	})
+	t.Run("headers", func(t *testing.T) {
+		got := testFunc(t, &HTTPConfig{URL: "/header",
+			Headers: map[string]string{"X-Check": "yes"}})
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: t.Name()}])
+	})
+	t.Run("bad status", func(t *testing.T) {
+		got := testFunc(t, &HTTPConfig{URL: "/unavailable"})
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitFailed, Source: t.Name()}])
+	})
+	t.Run("expected status", func(t *testing.T) {
+		got := testFunc(t, &HTTPConfig{URL: "/unavailable", Status: "500-599"})
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: t.Name()}])
+	})
+	t.Run("timeout", func(t *testing.T) {
+		got := testFunc(t, &HTTPConfig{URL: "/slow"})
+		assert.Equal(t, 1, got[events.Event{Code: events.ExitFailed, Source: t.Name()}])
+	})
+}
+
+// test helpers
+
+type runner interface {
+	Run(context.Context, *events.EventBus)
+}
+
+// runtestCheck runs the check and collects the events it publishes until
+// its result is in, so that we never read the events while the check's
+// goroutine could still be publishing them
+func runtestCheck(check runner) map[events.Event]int {
+	bus := events.NewEventBus()
+	sub := &events.Subscriber{Rx: make(chan events.Event, 10)}
+	sub.Subscribe(bus)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	check.Run(ctx, bus)
+	got := map[events.Event]int{}
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event := <-sub.Rx:
+			got[event]++
+			// a failed check publishes its Error after the ExitFailed
+			if event.Code == events.ExitSuccess || event.Code == events.Error {
+				return got
+			}
+		case <-timeout:
+			return got
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+// TCPConfig configures a health check that opens a TCP connection
+type TCPConfig struct {
+	Address string `mapstructure:"address"` // host:port
+}
+
+// TCPCheck is a health check that passes if a TCP connection can be
+// established to its address.
+type TCPCheck struct {
+	Name    string
+	Address string
+	Timeout time.Duration
+}
+
+// NewTCPCheck validates a TCPConfig and creates a TCPCheck from it
+func NewTCPCheck(name string, cfg *TCPConfig, timeout time.Duration) (*TCPCheck, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("'address' must not be blank")
+	}
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("could not parse 'address': %v", err)
+	}
+	return &TCPCheck{
+		Name:    name,
+		Address: cfg.Address,
+		Timeout: timeout,
+	}, nil
+}
+
+// Run opens the TCP connection asynchronously and publishes the result
+// of the check to the EventBus.
+func (check *TCPCheck) Run(pctx context.Context, bus *events.EventBus) {
+	run(pctx, bus, check.Name, check.Timeout, check.check)
+}
+
+func (check *TCPCheck) check(ctx context.Context) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", check.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// String implements the stdlib fmt.Stringer interface for pretty-printing
+func (check *TCPCheck) String() string {
+	return "checks.TCPCheck[" + check.Name + "]"
+}
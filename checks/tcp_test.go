@@ -0,0 +1,38 @@
+package checks
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+func TestTCPCheckConfigError(t *testing.T) {
+	_, err := NewTCPCheck("check.myjob", &TCPConfig{}, time.Second)
+	assert.Equal(t, "'address' must not be blank", err.Error())
+
+	_, err = NewTCPCheck("check.myjob", &TCPConfig{Address: "localhost"}, time.Second)
+	assert.Equal(t,
+		"could not parse 'address': address localhost: missing port in address",
+		err.Error())
+}
+
+func TestTCPCheckRun(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	check, _ := NewTCPCheck("check.tcpOk", &TCPConfig{Address: addr}, time.Second)
+	got := runtestCheck(check)
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: "check.tcpOk"}])
+
+	ln.Close() // nothing listening on this port anymore
+	check, _ = NewTCPCheck("check.tcpFail", &TCPConfig{Address: addr}, time.Second)
+	got = runtestCheck(check)
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitFailed, Source: "check.tcpFail"}])
+}
@@ -3,10 +3,15 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -41,29 +46,101 @@ func NewHTTPClient(socketPath string) (*HTTPClient, error) {
 	return client, nil
 }
 
-// Reload makes a request to the reload endpoint of a ContainerPilot process.
-func (c HTTPClient) Reload() error {
+// Reload makes a request to the reload endpoint of a ContainerPilot process,
+// and returns the JSON differences in the configuration that was reloaded.
+func (c HTTPClient) Reload() (string, error) {
 	resp, err := c.Post("http://control/v3/reload", "application/json", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return "", fmt.Errorf("invalid configuration: %s",
+			strings.TrimSpace(string(body)))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// SetMaintenance makes a request to either the enable or disable maintenance
+// endpoint of a ContainerPilot process. When enabling maintenance, the
+// reason and TTL are optional.
+func (c HTTPClient) SetMaintenance(isEnabled bool, reason, ttl string) error {
+	flag := "disable"
+	if isEnabled {
+		flag = "enable"
+	}
+
+	resp, err := c.Post("http://control/v3/maintenance/"+flag, "application/json",
+		maintenanceBody(isEnabled, reason, ttl))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return fmt.Errorf("unprocessable entity received by control server")
+	}
 	return nil
 }
 
-// SetMaintenance makes a request to either the enable or disable maintenance
-// endpoint of a ContainerPilot process.
-func (c HTTPClient) SetMaintenance(isEnabled bool) error {
+// maintenanceBody returns the JSON body of a request to enable maintenance
+// mode, or nil if there's nothing to send
+func maintenanceBody(isEnabled bool, reason, ttl string) io.Reader {
+	if !isEnabled || (reason == "" && ttl == "") {
+		return nil
+	}
+	body, _ := json.Marshal(map[string]string{"reason": reason, "ttl": ttl})
+	return bytes.NewReader(body)
+}
+
+// SetJobMaintenance makes a request to either the enable or disable
+// maintenance endpoint of a job in a ContainerPilot process. When enabling
+// maintenance, the reason and TTL are optional.
+func (c HTTPClient) SetJobMaintenance(name string, isEnabled bool, reason, ttl string) error {
 	flag := "disable"
 	if isEnabled {
 		flag = "enable"
 	}
+	return c.postJob(name, "maintenance/"+flag, maintenanceBody(isEnabled, reason, ttl))
+}
 
-	resp, err := c.Post("http://control/v3/maintenance/"+flag, "application/json", nil)
+// StartJob makes a request to the start endpoint of a job in a
+// ContainerPilot process.
+func (c HTTPClient) StartJob(name string) error {
+	return c.postJob(name, "start", nil)
+}
+
+// StopJob makes a request to the stop endpoint of a job in a
+// ContainerPilot process.
+func (c HTTPClient) StopJob(name string) error {
+	return c.postJob(name, "stop", nil)
+}
+
+// RestartJob makes a request to the restart endpoint of a job in a
+// ContainerPilot process.
+func (c HTTPClient) RestartJob(name string) error {
+	return c.postJob(name, "restart", nil)
+}
+
+func (c HTTPClient) postJob(name, action string, body io.Reader) error {
+	resp, err := c.Post("http://control/v3/jobs/"+url.PathEscape(name)+"/"+action,
+		"application/json", body)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("job '%s' not found by control server", name)
+	case http.StatusUnprocessableEntity:
+		return fmt.Errorf("unprocessable entity received by control server")
+	}
 	return nil
 }
 
@@ -6,6 +6,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -30,6 +31,21 @@ type Command struct {
 	logger  log.Entry
 	lock    *sync.Mutex
 	fields  log.Fields
+
+	// OutputLimit is the number of bytes of combined stdout/stderr of each
+	// run to keep for Output. Output isn't captured if it's zero.
+	OutputLimit int
+
+	exitCode   int
+	output     string
+	process    *os.Process // set once the current run has started
+	resultLock *sync.RWMutex
+
+	// a Command created by NewStub runs its Stubs on the clock
+	clock       events.Clock
+	stubs       Stubs
+	stubRunning bool
+	stubQueue   []func()
 }
 
 // NewCommand parses JSON config into a Command
@@ -39,11 +55,12 @@ func NewCommand(rawArgs interface{}, timeout time.Duration, fields log.Fields) (
 		return nil, err
 	}
 	cmd := &Command{
-		Name:    exec, // override this in caller
-		Exec:    exec,
-		Args:    args,
-		Timeout: timeout,
-		lock:    &sync.Mutex{},
+		Name:       exec, // override this in caller
+		Exec:       exec,
+		Args:       args,
+		Timeout:    timeout,
+		lock:       &sync.Mutex{},
+		resultLock: &sync.RWMutex{},
 	} // exec.Cmd created at Run
 
 	if fields != nil {
@@ -84,23 +101,48 @@ func (c *Command) EnvName() string {
 // If the parent context is closed/canceled this will terminate the
 // child process and do any cleanup we need.
 func (c *Command) Run(pctx context.Context, bus *events.EventBus) {
+	c.RunWithEnv(pctx, bus, nil)
+}
+
+// RunWithEnv runs the Command like Run, with the "KEY=value" pairs in env
+// added to the environment that the process inherits from ContainerPilot.
+func (c *Command) RunWithEnv(pctx context.Context, bus *events.EventBus, env []string) {
 	if c == nil {
 		log.Debugf("nothing to run for %s", c.Name)
 		return
 	}
+	if c.stubs != nil {
+		c.runStub(pctx, bus)
+		return
+	}
 	// we should never have more than one instance running for any
 	// realistic configuration but this ensures that's the case
 	c.lock.Lock()
 	log.Debugf("%s.Run start", c.Name)
+	c.setProcess(nil)
 
 	cmd := exec.Command(c.Exec, c.Args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var outputReader *io.PipeReader
+	var outputWriter *io.PipeWriter
 	if c.logger.Logger != nil {
-		cmd.Stdout = c.logger.Writer()
-		cmd.Stderr = c.logger.Writer()
+		// the output is logged with the pid, which we don't know until the
+		// process has started, so it's read from the pipe only after that
+		outputReader, outputWriter = io.Pipe()
+		cmd.Stdout = outputWriter
+		cmd.Stderr = outputWriter
 	} else {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 	}
+	var output *outputBuffer
+	if c.OutputLimit > 0 {
+		output = newOutputBuffer(c.OutputLimit)
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, output)
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, output)
+	}
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	c.Cmd = cmd
 	ctx, cancel := getContext(pctx, c.Timeout)
@@ -126,10 +168,15 @@ func (c *Command) Run(pctx context.Context, bus *events.EventBus) {
 		defer log.Debugf("%s.Run end", c.Name)
 		if err := c.Cmd.Start(); err != nil {
 			log.Errorf("unable to start %s: %v", c.Name, err)
+			c.setResult(-1, err.Error())
+			if outputWriter != nil {
+				outputWriter.Close()
+			}
 			bus.Publish(events.Event{events.ExitFailed, c.Name})
 			bus.Publish(events.Event{events.Error, err.Error()})
 			return
 		}
+		c.setProcess(c.Cmd.Process)
 
 		// if we're able to, log the PID of our Command's exec process through
 		// our logger fields
@@ -140,15 +187,19 @@ func (c *Command) Run(pctx context.Context, bus *events.EventBus) {
 			os.Setenv(envName, strconv.Itoa(pid))
 			defer os.Unsetenv(envName)
 
-			if len(c.fields) > 0 {
-				c.fields["pid"] = pid
-				c.logger = *log.WithFields(c.fields)
+			if outputReader != nil {
+				go c.logOutput(outputReader, pid)
 			}
 		}
 
 		// blocks this goroutine here; if the context gets cancelled
 		// we'll return from Wait() and publish events
-		if err := c.Cmd.Wait(); err != nil {
+		err := c.Cmd.Wait()
+		if outputWriter != nil {
+			outputWriter.Close()
+		}
+		c.setResult(exitCodeFromError(err), output.String())
+		if err != nil {
 			log.Errorf("%s exited with error: %v", c.Name, err)
 			bus.Publish(events.Event{events.ExitFailed, c.Name})
 			bus.Publish(events.Event{events.Error,
@@ -160,6 +211,69 @@ func (c *Command) Run(pctx context.Context, bus *events.EventBus) {
 	}()
 }
 
+// logOutput logs each line of the process output read from r with the
+// Command's fields and the pid of the process
+func (c *Command) logOutput(r io.Reader, pid int) {
+	fields := log.Fields{}
+	for k, v := range c.fields {
+		fields[k] = v
+	}
+	fields["pid"] = pid
+	w := log.WithFields(fields).Writer()
+	defer w.Close()
+	io.Copy(w, r)
+}
+
+// ExitCode returns the exit code of the most recent run of the Command.
+// Returns -1 if the process couldn't be started or was killed by a signal.
+func (c *Command) ExitCode() int {
+	c.resultLock.RLock()
+	defer c.resultLock.RUnlock()
+	return c.exitCode
+}
+
+// Output returns the combined stdout/stderr of the most recent run of the
+// Command, truncated to its OutputLimit, or the error if the process
+// couldn't be started.
+func (c *Command) Output() string {
+	c.resultLock.RLock()
+	defer c.resultLock.RUnlock()
+	return c.output
+}
+
+func (c *Command) setResult(code int, output string) {
+	c.resultLock.Lock()
+	defer c.resultLock.Unlock()
+	c.exitCode = code
+	c.output = output
+}
+
+// setProcess records the process of the current run, so that Kill and
+// Term don't read it from the exec.Cmd while Start is still writing it
+func (c *Command) setProcess(process *os.Process) {
+	c.resultLock.Lock()
+	defer c.resultLock.Unlock()
+	c.process = process
+}
+
+func (c *Command) getProcess() *os.Process {
+	c.resultLock.RLock()
+	defer c.resultLock.RUnlock()
+	return c.process
+}
+
+func exitCodeFromError(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+	return -1
+}
+
 func getContext(pctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout > 0 {
 		return context.WithTimeout(pctx, timeout)
@@ -171,9 +285,9 @@ func getContext(pctx context.Context, timeout time.Duration) (context.Context, c
 // as well as all its children
 func (c *Command) Kill() {
 	log.Debugf("%s.kill", c.Name)
-	if c.Cmd != nil && c.Cmd.Process != nil {
-		log.Debugf("killing command '%v' at pid: %d", c.Name, c.Cmd.Process.Pid)
-		syscall.Kill(-c.Cmd.Process.Pid, syscall.SIGKILL)
+	if process := c.getProcess(); process != nil {
+		log.Debugf("killing command '%v' at pid: %d", c.Name, process.Pid)
+		syscall.Kill(-process.Pid, syscall.SIGKILL)
 	}
 }
 
@@ -181,8 +295,8 @@ func (c *Command) Kill() {
 // as well as all its children
 func (c *Command) Term() {
 	log.Debugf("%s.term", c.Name)
-	if c.Cmd != nil && c.Cmd.Process != nil {
-		log.Debugf("terminating command '%v' at pid: %d", c.Name, c.Cmd.Process.Pid)
-		syscall.Kill(-c.Cmd.Process.Pid, syscall.SIGTERM)
+	if process := c.getProcess(); process != nil {
+		log.Debugf("terminating command '%v' at pid: %d", c.Name, process.Pid)
+		syscall.Kill(-process.Pid, syscall.SIGTERM)
 	}
 }
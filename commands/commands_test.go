@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -54,6 +57,7 @@ func TestCommandRunExecFailed(t *testing.T) {
 	if got[failed] != 1 || got[errMsg] != 1 {
 		t.Fatalf("expected:\n%v\n%v\ngot events:\n%v", failed, errMsg, got)
 	}
+	assert.Equal(t, 255, cmd.ExitCode(), "exit code after failed exec")
 }
 
 func TestCommandRunExecInvalid(t *testing.T) {
@@ -65,6 +69,7 @@ func TestCommandRunExecInvalid(t *testing.T) {
 	if got[failed] != 1 || got[errMsg] != 1 {
 		t.Fatalf("expected:\n%v\n%v\ngot events:\n%v", failed, errMsg, got)
 	}
+	assert.Equal(t, -1, cmd.ExitCode(), "exit code after invalid exec")
 }
 
 func TestEmptyCommand(t *testing.T) {
@@ -89,6 +94,82 @@ func TestCommandPassthru(t *testing.T) {
 	assert.NotEqual(t, cmd.Cmd.Stdout, os.Stdout)
 }
 
+func TestCommandOutput(t *testing.T) {
+	cmd, _ := NewCommand("./testdata/test.sh failStuff --debug",
+		time.Duration(0), log.Fields{"job": "failDat"})
+	runtestCommandRun(cmd)
+	assert.Equal(t, "", cmd.Output(), "output should not be captured by default")
+
+	cmd.OutputLimit = 1024
+	runtestCommandRun(cmd)
+	assert.Equal(t, "Running failStuff with args: --debug\n", cmd.Output())
+
+	cmd.OutputLimit = 7
+	runtestCommandRun(cmd)
+	assert.Equal(t, "Running", cmd.Output(), "output should be truncated")
+}
+
+func TestCommandRunWithEnv(t *testing.T) {
+	cmd, _ := NewCommand([]string{"sh", "-c", "echo $TEST_RUN_ENV"},
+		time.Duration(0), nil)
+	cmd.OutputLimit = 1024
+	bus := events.NewEventBus()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	cmd.RunWithEnv(ctx, bus, []string{"TEST_RUN_ENV=hello"})
+	time.Sleep(300 * time.Millisecond)
+	bus.Wait()
+	assert.Equal(t, "hello\n", cmd.Output())
+}
+
+// lockedBuffer is a bytes.Buffer that's safe to read while the logger
+// writes to it
+type lockedBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]byte{}, b.buf.Bytes()...)
+}
+
+func TestCommandOutputLoggedWithPid(t *testing.T) {
+	buf := &lockedBuffer{}
+	log.SetOutput(buf)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFormatter(&log.TextFormatter{})
+	}()
+
+	cmd, _ := NewCommand([]string{"sh", "-c", "echo $$"},
+		time.Duration(0), log.Fields{"job": "pidDat"})
+	cmd.OutputLimit = 1024
+	runtestCommandRun(cmd)
+
+	var entry struct {
+		Job string
+		Msg string
+		Pid int
+	}
+	for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+		if bytes.Contains(line, []byte(`"job":"pidDat"`)) {
+			json.Unmarshal(line, &entry)
+		}
+	}
+	assert.Equal(t, "pidDat", entry.Job)
+	assert.Equal(t, fmt.Sprintf("%d\n", entry.Pid), cmd.Output(),
+		"expected the logged pid to be the pid of the process")
+}
+
 func TestEnvName(t *testing.T) {
 	tests := []struct {
 		name, input, output string
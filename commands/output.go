@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"bytes"
+	"sync"
+)
+
+// outputBuffer is an io.Writer that keeps only the first 'limit' bytes
+// written to it. It's shared between a process' stdout and stderr, which
+// os/exec may write to concurrently.
+type outputBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	lock  sync.Mutex
+}
+
+func newOutputBuffer(limit int) *outputBuffer {
+	return &outputBuffer{limit: limit}
+}
+
+// Write implements io.Writer. It never returns an error so that a full
+// buffer doesn't interrupt the process' other output.
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if remain := b.limit - b.buf.Len(); remain > 0 {
+		if len(p) > remain {
+			b.buf.Write(p[:remain])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// String returns the buffered output, and is safe to call on a nil
+// outputBuffer for Commands that don't capture their output
+func (b *outputBuffer) String() string {
+	if b == nil {
+		return ""
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joyent/containerpilot/events"
+	log "github.com/sirupsen/logrus"
+)
+
+// Stub is the simulated result of a run of a Command or health check,
+// used in place of running a real process when ContainerPilot is
+// simulating its configuration. A Duration of Forever never exits on its
+// own.
+type Stub struct {
+	ExitCode int
+	Duration time.Duration
+	Output   string
+}
+
+// Forever is a Stub Duration for a process that runs until it's stopped
+const Forever = time.Duration(-1)
+
+// forever is how long the clock waits on a Stub that runs until it's
+// stopped, so that it still hears when its context is done
+const forever = time.Duration(1 << 62)
+
+// Stubs returns the Stub for the next run of the named Command or health
+// check
+type Stubs func(name string) Stub
+
+// NewStub creates a Command that, each time it's run, runs the Stub that
+// stubs returns for its name, timed on the clock, in place of a process.
+func NewStub(name string, timeout time.Duration, clock events.Clock, stubs Stubs) *Command {
+	return &Command{
+		Name:       name,
+		Exec:       name,
+		Timeout:    timeout,
+		lock:       &sync.Mutex{},
+		resultLock: &sync.RWMutex{},
+		clock:      clock,
+		stubs:      stubs,
+	}
+}
+
+// runStub publishes the events for a simulated run of the Command just
+// as Run does for a real process. The run waits on the clock for the
+// Stub's Duration, or until the context is done or the timeout (if any)
+// expires. A real run waits for the one before it to exit while holding
+// up its caller, but that would stop the clock, so a simulated run is
+// queued to start once the one before it exits instead.
+func (c *Command) runStub(pctx context.Context, bus *events.EventBus) {
+	run := func() { c.startStub(pctx, bus) }
+	c.lock.Lock()
+	if c.stubRunning {
+		c.stubQueue = append(c.stubQueue, run)
+		c.lock.Unlock()
+		return
+	}
+	c.stubRunning = true
+	c.lock.Unlock()
+	run()
+}
+
+func (c *Command) startStub(ctx context.Context, bus *events.EventBus) {
+	stub := c.stubs(c.Name)
+	log.Debugf("%s.Run start (simulated)", c.Name)
+	ctx, cancel := context.WithCancel(ctx)
+	var once sync.Once
+	exit := func(code int, err error) {
+		once.Do(func() {
+			cancel()
+			c.setResult(code, stub.Output)
+			if err != nil {
+				bus.Publish(events.Event{Code: events.ExitFailed, Source: c.Name})
+				bus.Publish(events.Event{Code: events.Error,
+					Source: fmt.Errorf("%s: %s", c.Name, err).Error()})
+			} else {
+				bus.Publish(events.Event{Code: events.ExitSuccess, Source: c.Name})
+			}
+			log.Debugf("%s.Run end (simulated)", c.Name)
+			c.nextStub()
+		})
+	}
+	terminated := errors.New("signal: terminated")
+
+	duration := stub.Duration
+	if duration == Forever {
+		duration = forever
+	}
+	c.clock.AfterFunc(ctx, duration, func(err error) {
+		switch {
+		case err != nil:
+			exit(-1, terminated)
+		case stub.ExitCode != 0:
+			exit(stub.ExitCode, fmt.Errorf("exit status %d", stub.ExitCode))
+		default:
+			exit(0, nil)
+		}
+	})
+	if c.Timeout > 0 {
+		c.clock.AfterFunc(ctx, c.Timeout, func(err error) {
+			if err != nil {
+				exit(-1, terminated)
+				return
+			}
+			exit(-1, errors.New("signal: killed"))
+		})
+	}
+}
+
+// nextStub starts the next queued run of the Stub, if any
+func (c *Command) nextStub() {
+	c.lock.Lock()
+	if len(c.stubQueue) == 0 {
+		c.stubRunning = false
+		c.lock.Unlock()
+		return
+	}
+	run := c.stubQueue[0]
+	c.stubQueue = c.stubQueue[1:]
+	c.lock.Unlock()
+	run()
+}
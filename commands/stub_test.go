@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joyent/containerpilot/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandRunStub(t *testing.T) {
+	cmd := NewStub(t.Name(), time.Duration(0), events.WallClock{},
+		func(name string) Stub {
+			return Stub{ExitCode: 3, Output: "stubbed"}
+		})
+	got := runtestCommandRun(cmd)
+	failed := events.Event{events.ExitFailed, t.Name()}
+	errMsg := events.Event{events.Error, t.Name() + ": exit status 3"}
+	if got[failed] != 1 || got[errMsg] != 1 {
+		t.Fatalf("expected:\n%v\n%v\ngot events:\n%v", failed, errMsg, got)
+	}
+	assert.Equal(t, 3, cmd.ExitCode(), "exit code after stubbed exec")
+	assert.Equal(t, "stubbed", cmd.Output(), "output after stubbed exec")
+}
+
+func TestStubVirtualClock(t *testing.T) {
+	start := time.Now()
+	clock := events.NewVirtualClock(start)
+	bus := events.NewEventBus()
+	run := func(stub Stub, timeout time.Duration) *Command {
+		cmd := NewStub(t.Name(), timeout, clock,
+			func(name string) Stub { return stub })
+		cmd.Run(context.Background(), bus)
+		return cmd
+	}
+	exited := events.Event{events.ExitSuccess, t.Name()}
+	failed := events.Event{events.ExitFailed, t.Name()}
+
+	run(Stub{Duration: time.Minute}, 0)
+	clock.AdvanceTo(start.Add(time.Minute - time.Second))
+	assert.Empty(t, bus.DebugEvents(), "stub exited early")
+	clock.AdvanceTo(start.Add(time.Minute))
+	assert.Equal(t, []events.Event{exited}, bus.DebugEvents())
+
+	cmd := run(Stub{Duration: Forever}, time.Second)
+	clock.AdvanceTo(start.Add(time.Minute + time.Second))
+	assert.Equal(t, []events.Event{failed,
+		{events.Error, t.Name() + ": signal: killed"}}, bus.DebugEvents())
+	assert.Equal(t, -1, cmd.ExitCode())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd = NewStub(t.Name(), 0, clock,
+		func(name string) Stub { return Stub{Duration: Forever} })
+	cmd.Run(ctx, bus)
+	cmd.Run(ctx, bus) // queued until the first run exits
+	cancel()
+	clock.Settle(bus)
+	assert.Equal(t, []events.Event{
+		failed, {events.Error, t.Name() + ": signal: terminated"},
+		failed, {events.Error, t.Name() + ": signal: terminated"},
+	}, bus.DebugEvents())
+	_, waiting := clock.Next()
+	assert.False(t, waiting, "cancelled stubs still waiting on the clock")
+}
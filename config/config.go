@@ -29,6 +29,7 @@ type rawConfig struct {
 	watches     []interface{}
 	telemetry   interface{}
 	control     interface{}
+	watchConfig bool
 }
 
 // Config contains the parsed config elements
@@ -40,6 +41,13 @@ type Config struct {
 	Watches     []*watches.Config
 	Telemetry   *telemetry.Config
 	Control     *control.Config
+	WatchConfig bool
+
+	// the raw configuration of each job and watch by name, and of
+	// everything else, for comparing against a reloaded configuration
+	rawJobs     map[string]interface{}
+	rawWatches  map[string]interface{}
+	rawSettings map[string]interface{}
 }
 
 const (
@@ -104,6 +112,21 @@ func LoadConfig(configFlag string) (*Config, error) {
 	return config, nil
 }
 
+// LoadConfigWithDiscovery loads the configuration like LoadConfig, but
+// uses the discovery backend in place of the one in the configuration.
+// This is used to simulate the configuration without a real Consul.
+func LoadConfigWithDiscovery(configFlag string, disc discovery.Backend) (*Config, error) {
+	configData, err := loadConfigFile(configFlag)
+	if err != nil {
+		return nil, err
+	}
+	renderedConfig, err := renderConfigTemplate(configData)
+	if err != nil {
+		return nil, err
+	}
+	return newConfigWithDiscovery(renderedConfig, disc)
+}
+
 func loadConfigFile(configFlag string) ([]byte, error) {
 	if configFlag == "" {
 		return nil, errors.New("-config flag is required")
@@ -126,24 +149,35 @@ func renderConfigTemplate(configData []byte) ([]byte, error) {
 // newConfig unmarshals the textual configuration data into the
 // validated Config struct that we'll use the run the application
 func newConfig(configData []byte) (*Config, error) {
+	return newConfigWithDiscovery(configData, nil)
+}
+
+func newConfigWithDiscovery(configData []byte, disc discovery.Backend) (*Config, error) {
 	configMap, err := unmarshalConfig(configData)
 	if err != nil {
 		return nil, err
 	}
 
+	cfg := &Config{rawSettings: map[string]interface{}{}}
+	for _, key := range settingsKeys {
+		cfg.rawSettings[key] = configMap[key]
+	}
 	raw := &rawConfig{}
 	if err = decodeConfig(configMap, raw); err != nil {
 		return nil, err
 	}
-	cfg := &Config{}
 
-	disc, err := discovery.NewConsul(raw.consul)
-	if err != nil {
-		return nil, err
+	if disc == nil {
+		consul, err := discovery.NewConsul(raw.consul)
+		if err != nil {
+			return nil, err
+		}
+		disc = consul
 	}
 	cfg.Discovery = disc
 
 	cfg.LogConfig = raw.logConfig
+	cfg.WatchConfig = raw.watchConfig
 
 	stopTimeout, err := raw.parseStopTimeout()
 	if err != nil {
@@ -162,12 +196,20 @@ func newConfig(configData []byte) (*Config, error) {
 		return nil, fmt.Errorf("unable to parse jobs: %v", err)
 	}
 	cfg.Jobs = jobConfigs
+	cfg.rawJobs = map[string]interface{}{}
+	for i, job := range jobConfigs {
+		cfg.rawJobs[job.Name] = raw.jobs[i]
+	}
 
 	watches, err := watches.NewConfigs(raw.watches, disc)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse watches: %v", err)
 	}
 	cfg.Watches = watches
+	cfg.rawWatches = map[string]interface{}{}
+	for i, watch := range watches {
+		cfg.rawWatches[strings.TrimPrefix(watch.Name, "watch.")] = raw.watches[i]
+	}
 
 	telemetry, err := telemetry.NewConfig(raw.telemetry, disc)
 	if err != nil {
@@ -178,6 +220,14 @@ func newConfig(configData []byte) (*Config, error) {
 		cfg.Jobs = append(cfg.Jobs, telemetry.JobConfig)
 	}
 
+	watchNames := []string{}
+	for _, watch := range cfg.Watches {
+		watchNames = append(watchNames, watch.Name)
+	}
+	if err := jobs.ValidateGraph(cfg.Jobs, watchNames); err != nil {
+		return nil, fmt.Errorf("unable to parse jobs: %v", err)
+	}
+
 	return cfg, nil
 }
 
@@ -214,8 +264,8 @@ func highlightError(data []byte, pos int64) (int, int, string) {
 		prevLine = thisLine
 		thisLine = fmt.Sprintf("%5d: %s\n", line, scanner.Text())
 		readBytes := int64(len(scanner.Bytes()))
-		offset += readBytes
-		if offset >= pos-1 {
+		offset += readBytes + 1 // including the newline
+		if offset >= pos {
 			count := int(7 + col - 1)
 			if count > 0 {
 				highlight = fmt.Sprintf("%s^", strings.Repeat("-", count))
@@ -237,12 +287,16 @@ func highlightError(data []byte, pos int64) (int, int, string) {
 func decodeConfig(configMap map[string]interface{}, result *rawConfig) error {
 	var logConfig logger.Config
 	var stopTimeout int
+	var watchConfig bool
 	if err := decode.ToStruct(configMap["logging"], &logConfig); err != nil {
 		return err
 	}
 	if err := decode.ToStruct(configMap["stopTimeout"], &stopTimeout); err != nil {
 		return err
 	}
+	if err := decode.ToStruct(configMap["watchConfig"], &watchConfig); err != nil {
+		return err
+	}
 	result.consul = configMap["consul"]
 	result.stopTimeout = stopTimeout
 	result.logConfig = &logConfig
@@ -250,20 +304,10 @@ func decodeConfig(configMap map[string]interface{}, result *rawConfig) error {
 	result.jobs = decode.ToSlice(configMap["jobs"])
 	result.watches = decode.ToSlice(configMap["watches"])
 	result.telemetry = configMap["telemetry"]
+	result.watchConfig = watchConfig
 
-	delete(configMap, "consul")
-	delete(configMap, "logging")
-	delete(configMap, "control")
-	delete(configMap, "stopTimeout")
-	delete(configMap, "jobs")
-	delete(configMap, "watches")
-	delete(configMap, "telemetry")
-	var unused []string
-	for key := range configMap {
-		unused = append(unused, key)
-	}
-	if len(unused) > 0 {
-		return fmt.Errorf("unknown config keys: %v", unused)
+	if unknown := configSchema.unknownKeys(configMap, ""); len(unknown) > 0 {
+		return fmt.Errorf("unknown config keys: %v", unknown)
 	}
 	return nil
 }
@@ -209,6 +209,31 @@ func TestRenderedConfigIsParseable(t *testing.T) {
 	}
 }
 
+func TestValidConfigWatchConfig(t *testing.T) {
+	cfg, err := newConfig([]byte(`{consul: "consul:8500"}`))
+	if err != nil {
+		t.Fatalf("unexpected error in LoadConfig: %v", err)
+	}
+	assert.False(t, cfg.WatchConfig, "expected watchConfig to default to false")
+
+	cfg, err = newConfig([]byte(`{consul: "consul:8500", watchConfig: true}`))
+	if err != nil {
+		t.Fatalf("unexpected error in LoadConfig: %v", err)
+	}
+	assert.True(t, cfg.WatchConfig, "expected watchConfig to be parsed")
+}
+
+func TestInvalidConfigJobsGraph(t *testing.T) {
+	var testJSON = `{
+	"consul": "consul:8500",
+	jobs: [{name: "app", exec: "true", when: {source: "watch.db", once: "changed"}}],
+	watches: [{name: "database", interval: 10}]}`
+
+	_, err := newConfig([]byte(testJSON))
+	assert.EqualError(t, err,
+		"unable to parse jobs: job[app].when.source 'watch.db' does not match any job or watch")
+}
+
 // ----------------------------------------------------
 // test helpers
 
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// settingsKeys are the top-level config keys other than the jobs and
+// watches. A change to any of these can't be applied to the running jobs
+// and watches, so everything has to be restarted.
+var settingsKeys = []string{
+	"consul", "logging", "stopTimeout", "control", "telemetry", "watchConfig"}
+
+// Diff is the difference between a configuration and a newer one, such
+// as one loaded on reload, by the names of the jobs and watches
+type Diff struct {
+	Jobs    Changes `json:"jobs"`
+	Watches Changes `json:"watches"`
+
+	// Restart is true when something other than the jobs and watches
+	// has changed, such as the Consul or telemetry configuration
+	Restart bool `json:"restart,omitempty"`
+}
+
+// Changes are the names of the jobs or watches that were added, removed,
+// or changed. Anything not named is unchanged.
+type Changes struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Diff compares the raw configuration of each job and watch in the
+// configuration with those in the newer configuration. A job also
+// changes when the job that runs on its "stopping" event changes,
+// because it has to be restarted to wait for the new one when it stops.
+func (cfg *Config) Diff(newer *Config) *Diff {
+	diff := &Diff{
+		Jobs:    diffRaw(cfg.rawJobs, newer.rawJobs),
+		Watches: diffRaw(cfg.rawWatches, newer.rawWatches),
+		Restart: !reflect.DeepEqual(cfg.rawSettings, newer.rawSettings),
+	}
+	diff.Jobs.Changed = diffStopping(cfg, newer, diff.Jobs.Changed)
+	return diff
+}
+
+// diffStopping adds the jobs that are otherwise unchanged but that have
+// a different "stopping" dependent to the changed jobs
+func diffStopping(old, newer *Config, changed []string) []string {
+	dependents := map[string]string{}
+	for _, job := range old.Jobs {
+		dependents[job.Name] = job.StoppingDependent()
+	}
+	isChanged := map[string]bool{}
+	for _, name := range changed {
+		isChanged[name] = true
+	}
+	for _, job := range newer.Jobs {
+		dependent, ok := dependents[job.Name]
+		if !ok || isChanged[job.Name] {
+			continue
+		}
+		if dependent != job.StoppingDependent() {
+			changed = append(changed, job.Name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func diffRaw(old, newer map[string]interface{}) Changes {
+	var changes Changes
+	for name, raw := range newer {
+		oldRaw, ok := old[name]
+		if !ok {
+			changes.Added = append(changes.Added, name)
+		} else if !reflect.DeepEqual(oldRaw, raw) {
+			changes.Changed = append(changes.Changed, name)
+		}
+	}
+	for name := range old {
+		if _, ok := newer[name]; !ok {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+	return changes
+}
+
+// String implements the stdlib fmt.Stringer interface for logging
+func (diff *Diff) String() string {
+	out := fmt.Sprintf("jobs %s; watches %s", diff.Jobs, diff.Watches)
+	if diff.Restart {
+		out += "; other configuration changed"
+	}
+	return out
+}
+
+// String implements the stdlib fmt.Stringer interface for logging
+func (changes Changes) String() string {
+	parts := []string{}
+	if len(changes.Added) > 0 {
+		parts = append(parts, fmt.Sprintf("added %v", changes.Added))
+	}
+	if len(changes.Removed) > 0 {
+		parts = append(parts, fmt.Sprintf("removed %v", changes.Removed))
+	}
+	if len(changes.Changed) > 0 {
+		parts = append(parts, fmt.Sprintf("changed %v", changes.Changed))
+	}
+	if len(parts) == 0 {
+		return "unchanged"
+	}
+	return strings.Join(parts, ", ")
+}
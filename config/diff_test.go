@@ -0,0 +1,95 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDiff(t *testing.T) {
+	old, err := newConfig([]byte(`{
+	consul: "consul:8500",
+	jobs: [
+		{name: "db", exec: "/bin/db", port: 5432,
+		 health: {exec: "true", interval: 5, ttl: 10}},
+		{name: "app", exec: "/bin/app"},
+		{name: "cleanup", exec: "/bin/cleanup"}
+	],
+	watches: [{name: "upstream", interval: 10}, {name: "cache", interval: 10}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("jobs and watches", func(t *testing.T) {
+		newer, err := newConfig([]byte(`{
+		consul: "consul:8500",
+		jobs: [
+			{name: "db", exec: "/bin/db", port: 5432,
+			 health: {exec: "true", interval: 5, ttl: 10}},
+			{name: "app", exec: "/bin/app --verbose"},
+			{name: "debug", exec: "/bin/debug"}
+		],
+		watches: [{name: "upstream", interval: 10}, {name: "cache", interval: 5}]
+		}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := old.Diff(newer)
+		assert.Equal(t, Changes{
+			Added:   []string{"debug"},
+			Removed: []string{"cleanup"},
+			Changed: []string{"app"},
+		}, diff.Jobs)
+		assert.Equal(t, Changes{Changed: []string{"cache"}}, diff.Watches)
+		assert.False(t, diff.Restart)
+		assert.Equal(t,
+			"jobs added [debug], removed [cleanup], changed [app]; watches changed [cache]",
+			diff.String())
+	})
+
+	t.Run("stopping dependent", func(t *testing.T) {
+		preStop := `{name: "preStop", exec: "/bin/pre", when: {source: "app", once: "stopping"}}`
+		withPreStop, err := newConfig([]byte(`{
+		consul: "consul:8500",
+		jobs: [{name: "app", exec: "/bin/app"}, ` + preStop + `]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		withoutPreStop, err := newConfig([]byte(`{
+		consul: "consul:8500",
+		jobs: [{name: "app", exec: "/bin/app"}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, Changes{Removed: []string{"preStop"}, Changed: []string{"app"}},
+			withPreStop.Diff(withoutPreStop).Jobs)
+		assert.Equal(t, Changes{Added: []string{"preStop"}, Changed: []string{"app"}},
+			withoutPreStop.Diff(withPreStop).Jobs)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		diff := old.Diff(old)
+		assert.Equal(t, &Diff{}, diff)
+		assert.Equal(t, "jobs unchanged; watches unchanged", diff.String())
+	})
+
+	t.Run("other configuration", func(t *testing.T) {
+		newer, err := newConfig([]byte(`{
+		consul: "consul:8501",
+		jobs: [
+			{name: "db", exec: "/bin/db", port: 5432,
+			 health: {exec: "true", interval: 5, ttl: 10}},
+			{name: "app", exec: "/bin/app"},
+			{name: "cleanup", exec: "/bin/cleanup"}
+		],
+		watches: [{name: "upstream", interval: 10}, {name: "cache", interval: 10}]
+		}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := old.Diff(newer)
+		assert.True(t, diff.Restart)
+		assert.Equal(t, Changes{}, diff.Jobs)
+	})
+}
@@ -18,9 +18,9 @@ import (
 
 // Config configures the log levels
 type Config struct {
-	Level  string `json:"level"`
-	Format string `json:"format"`
-	Output string `json:"output"`
+	Level  string `json:"level" mapstructure:"level"`
+	Format string `json:"format" mapstructure:"format"`
+	Output string `json:"output" mapstructure:"output"`
 }
 
 var defaultLog = &Config{
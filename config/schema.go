@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/joyent/containerpilot/config/logger"
+	"github.com/joyent/containerpilot/control"
+	"github.com/joyent/containerpilot/discovery"
+	"github.com/joyent/containerpilot/jobs"
+	"github.com/joyent/containerpilot/telemetry"
+	"github.com/joyent/containerpilot/watches"
+)
+
+// Schema is a JSON Schema (draft 7) describing the configuration file,
+// or one part of it
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	OneOf      []*Schema          `json:"oneOf,omitempty"`
+
+	// AdditionalProperties is either false, when an object accepts only
+	// its Properties, or the *Schema for the values of a map
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+
+	// keys of the objects that are decoded into config structs match
+	// their Properties regardless of case, as they do in mapstructure
+	caseInsensitive bool
+}
+
+// configSchema is used to reject unknown keys in the configuration
+var configSchema = NewSchema()
+
+// NewSchema generates the JSON Schema for the configuration file from
+// the `mapstructure` tags of the config structs that each part of the
+// configuration is decoded into. Fields without a tag are derived while
+// validating the configuration, and aren't part of the schema.
+func NewSchema() *Schema {
+	return &Schema{
+		Schema: "http://json-schema.org/draft-07/schema#",
+		Title:  "ContainerPilot configuration",
+		Type:   "object",
+		Properties: map[string]*Schema{
+			"consul": {OneOf: []*Schema{
+				{Type: "string"},
+				schemaOf(reflect.TypeOf(discovery.ConsulConfig{})),
+			}},
+			"logging":     schemaOf(reflect.TypeOf(logger.Config{})),
+			"stopTimeout": {Type: "integer"},
+			"watchConfig": {Type: "boolean"},
+			"jobs":        schemaOf(reflect.TypeOf([]jobs.Config{})),
+			"watches":     schemaOf(reflect.TypeOf([]watches.Config{})),
+			"telemetry":   schemaOf(reflect.TypeOf(telemetry.Config{})),
+			"control":     schemaOf(reflect.TypeOf(control.Config{})),
+		},
+		AdditionalProperties: false,
+	}
+}
+
+func stringOrStrings() *Schema {
+	return &Schema{OneOf: []*Schema{
+		{Type: "string"},
+		{Type: "array", Items: &Schema{Type: "string"}},
+	}}
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{
+			Type:                 "object",
+			Properties:           map[string]*Schema{},
+			AdditionalProperties: false,
+			caseInsensitive:      true,
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			schema.Properties[name] = fieldSchema(t, field)
+		}
+		return schema
+	}
+	// an interface{} field that's not in fieldSchema accepts anything
+	return &Schema{}
+}
+
+// fieldSchema returns the schema of a field of a config struct. Fields
+// that accept more than one type are decoded as interface{}, so their
+// schemas can't be generated from their types.
+func fieldSchema(t reflect.Type, field reflect.StructField) *Schema {
+	switch t.String() + "." + field.Name {
+	case "jobs.Config.Exec", "jobs.HealthConfig.CheckExec",
+		"jobs.Config.Interfaces", "telemetry.Config.Interfaces":
+		return stringOrStrings()
+	case "jobs.Config.Restarts":
+		return &Schema{OneOf: []*Schema{{Type: "string"}, {Type: "integer"}}}
+	case "telemetry.Config.Metrics":
+		return schemaOf(reflect.TypeOf([]telemetry.MetricConfig{}))
+	}
+	return schemaOf(field.Type)
+}
+
+// CheckJobKeys returns an error naming the keys in the raw configuration
+// of a single job that the schema doesn't accept, so that a job added
+// through the control plane is checked just as the configuration file is
+func CheckJobKeys(raw map[string]interface{}) error {
+	jobSchema := configSchema.Properties["jobs"].Items
+	path := fmt.Sprintf("job[%v]", raw["name"])
+	if unknown := jobSchema.unknownKeys(raw, path); len(unknown) > 0 {
+		return fmt.Errorf("unknown config keys: %v", unknown)
+	}
+	return nil
+}
+
+// unknownKeys returns the JSON paths of every key in the raw configuration
+// that the schema doesn't accept
+func (schema *Schema) unknownKeys(raw interface{}, path string) []string {
+	var unknown []string
+	switch raw := raw.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range raw {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := joinPath(path, key)
+			if property, ok := schema.property(key); ok {
+				unknown = append(unknown, property.unknownKeys(raw[key], field)...)
+			} else if values, ok := schema.AdditionalProperties.(*Schema); ok {
+				unknown = append(unknown, values.unknownKeys(raw[key], field)...)
+			} else if schema.AdditionalProperties == false {
+				unknown = append(unknown, field)
+			}
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range raw {
+				itemPath := fmt.Sprintf("%s[%d]", path, i)
+				unknown = append(unknown, schema.Items.unknownKeys(item, itemPath)...)
+			}
+		}
+	}
+	for _, alternative := range schema.OneOf {
+		unknown = append(unknown, alternative.unknownKeys(raw, path)...)
+	}
+	return unknown
+}
+
+// property returns the schema of the value of the key in an object
+func (schema *Schema) property(key string) (*Schema, bool) {
+	if property, ok := schema.Properties[key]; ok {
+		return property, true
+	}
+	if schema.caseInsensitive {
+		for name, property := range schema.Properties {
+			if strings.EqualFold(name, key) {
+				return property, true
+			}
+		}
+	}
+	return nil, false
+}
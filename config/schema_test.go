@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaTopLevelKeys(t *testing.T) {
+	keys := []string{}
+	for key := range NewSchema().Properties {
+		keys = append(keys, key)
+	}
+	expected := append([]string{"jobs", "watches"}, settingsKeys...)
+	sort.Strings(keys)
+	sort.Strings(expected)
+	assert.Equal(t, expected, keys)
+}
+
+func TestSchemaFields(t *testing.T) {
+	schema := NewSchema()
+	job := schema.Properties["jobs"].Items
+	assert.Equal(t, "object", job.Type)
+	assert.Equal(t, false, job.AdditionalProperties)
+	assert.Equal(t, "integer", job.Properties["port"].Type)
+	assert.Len(t, job.Properties["exec"].OneOf, 2)
+	assert.Equal(t, "string",
+		job.Properties["when"].Properties["all"].Items.Properties["source"].Type)
+	assert.Equal(t, "string",
+		job.Properties["health"].Properties["http"].Properties["headers"].
+			AdditionalProperties.(*Schema).Type)
+
+	telem := schema.Properties["telemetry"]
+	assert.Equal(t, "object", telem.Properties["metrics"].Items.Type)
+	assert.Nil(t, telem.Properties["jobconfig"], "derived fields aren't in the schema")
+}
+
+func TestSchemaRejectsUnknownKeys(t *testing.T) {
+	_, err := newConfig([]byte(`{
+	consul: {address: "consul:8500", tls: {verify: true, bogus: 1}},
+	jobs: [
+		{
+			name: "app",
+			exec: ["/bin/app", "-v"],
+			when: {all: [{source: "db", event: "healthy", extra: 1}]},
+			health: {
+				exec: "/bin/check", interval: 1, ttl: 5,
+				http: {url: "http://x", headers: {"X-Anything": "ok"}}
+			}
+		}
+	],
+	telemetry: {jobconfig: {}, metrics: [{name: "m", type: "counter", nope: 1}]}
+}`))
+	assert.EqualError(t, err, "unknown config keys: "+
+		"[consul.tls.bogus jobs[0].when.all[0].extra "+
+		"telemetry.jobconfig telemetry.metrics[0].nope]")
+}
+
+func TestSchemaKeysIgnoreCase(t *testing.T) {
+	// mapstructure matches fields regardless of case, but the top-level
+	// keys are looked up as they're written
+	_, err := newConfig([]byte(`{
+	consul: "consul:8500",
+	jobs: [{Name: "a", EXEC: "true", When: {Source: "global", Once: "startup"}}]
+}`))
+	assert.NoError(t, err)
+
+	_, err = newConfig([]byte(`{consul: "consul:8500", Jobs: [{name: "a", exec: "true"}]}`))
+	assert.EqualError(t, err, "unknown config keys: [Jobs]")
+}
+
+func TestSchemaCheckJobKeys(t *testing.T) {
+	assert.NoError(t, CheckJobKeys(map[string]interface{}{
+		"name": "app", "exec": "true"}))
+	err := CheckJobKeys(map[string]interface{}{
+		"name": "app", "exec": "true",
+		"when": map[string]interface{}{"all": []interface{}{
+			map[string]interface{}{"source": "db", "event": "healthy", "extra": 1}}},
+	})
+	assert.EqualError(t, err, "unknown config keys: [job[app].when.all[0].extra]")
+}
+
+// the published schema is generated with `containerpilot -schema`
+func TestSchemaPublished(t *testing.T) {
+	published, err := ioutil.ReadFile(
+		"../docs/30-configuration/containerpilot.schema.json")
+	if err != nil {
+		t.Fatalf("could not read published schema: %v", err)
+	}
+	schemaJSON, err := json.MarshalIndent(NewSchema(), "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, string(published), string(schemaJSON)+"\n",
+		"published schema is out of date; regenerate it with -schema")
+}
@@ -0,0 +1,410 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/flynn/json5"
+
+	"github.com/joyent/containerpilot/config/decode"
+	"github.com/joyent/containerpilot/config/logger"
+	"github.com/joyent/containerpilot/control"
+	"github.com/joyent/containerpilot/discovery"
+	"github.com/joyent/containerpilot/jobs"
+	"github.com/joyent/containerpilot/telemetry"
+	"github.com/joyent/containerpilot/watches"
+)
+
+// ValidationError is an error in the configuration, along with the JSON
+// path to the part of the configuration that's invalid and the line and
+// column where that part starts in the rendered configuration
+type ValidationError struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+
+	highlight string
+}
+
+// Error implements the error interface, and includes the lines of the
+// rendered configuration around where the error was found
+func (err *ValidationError) Error() string {
+	path := err.Path
+	if path == "" {
+		path = "config"
+	}
+	return fmt.Sprintf("%s at line:col [%d:%d]: %s\n%s",
+		path, err.Line, err.Column, err.Message, err.highlight)
+}
+
+// ValidateConfig loads and renders the configuration like LoadConfig, but
+// validates each job, watch, and the rest of the configuration separately
+// so that it can return every error it finds rather than only the first.
+// The error is returned only if the configuration couldn't be read or
+// rendered at all.
+func ValidateConfig(configFlag string) ([]*ValidationError, error) {
+	configData, err := loadConfigFile(configFlag)
+	if err != nil {
+		return nil, err
+	}
+	renderedConfig, err := renderConfigTemplate(configData)
+	if err != nil {
+		return nil, err
+	}
+	return validateConfig(renderedConfig), nil
+}
+
+func validateConfig(configData []byte) []*ValidationError {
+	v := &validator{data: configData}
+	var configMap map[string]interface{}
+	if err := json5.Unmarshal(configData, &configMap); err != nil {
+		if syntax, ok := err.(*json5.SyntaxError); ok {
+			line, col, highlight := highlightError(configData, syntax.Offset)
+			v.errors = append(v.errors, &ValidationError{
+				Line: line, Column: col, Message: syntax.Error(),
+				highlight: highlight,
+			})
+		} else {
+			v.addAt("", fmt.Sprintf("could not parse configuration: %s", err))
+		}
+		return v.errors
+	}
+	v.offsets = sourceOffsets(configData)
+	v.validateSettings(configMap)
+
+	var disc discovery.Backend
+	consul, err := discovery.NewConsul(configMap["consul"])
+	if err != nil {
+		v.add("consul", err)
+	} else {
+		disc = consul
+	}
+	if _, err := control.NewConfig(configMap["control"]); err != nil {
+		v.add("control", err)
+	}
+
+	graphValid := true
+	jobConfigs := []*jobs.Config{}
+	jobPaths := map[string]string{}
+	rawJobs, _ := configMap["jobs"].([]interface{})
+	for i, raw := range rawJobs {
+		if raw == nil {
+			continue // ignored by LoadConfig too
+		}
+		path := fmt.Sprintf("jobs[%d]", i)
+		cfgs, err := jobs.NewConfigs([]interface{}{raw}, disc)
+		if err != nil {
+			v.add(path, err)
+			graphValid = false
+			continue
+		}
+		jobConfigs = append(jobConfigs, cfgs[0])
+		jobPaths[cfgs[0].Name] = path
+	}
+	watchNames := []string{}
+	rawWatches, _ := configMap["watches"].([]interface{})
+	for i, raw := range rawWatches {
+		if raw == nil {
+			continue
+		}
+		cfgs, err := watches.NewConfigs([]interface{}{raw}, disc)
+		if err != nil {
+			v.add(fmt.Sprintf("watches[%d]", i), err)
+			graphValid = false
+			continue
+		}
+		watchNames = append(watchNames, cfgs[0].Name)
+	}
+	telem, err := telemetry.NewConfig(configMap["telemetry"], disc)
+	if err != nil {
+		v.add("telemetry", err)
+		graphValid = false
+	} else if telem != nil {
+		jobConfigs = append(jobConfigs, telem.JobConfig)
+		jobPaths[telem.JobConfig.Name] = "telemetry"
+	}
+
+	// a job that's missing from the graph because it's invalid would
+	// only add errors for every job that depends on it
+	if graphValid {
+		if err := jobs.ValidateGraph(jobConfigs, watchNames); err != nil {
+			path := "jobs"
+			if match := fieldPattern.FindStringSubmatch(err.Error()); match != nil {
+				if jobPath, ok := jobPaths[match[1]]; ok {
+					path = joinPath(jobPath, strings.TrimPrefix(match[2], "."))
+				}
+			}
+			v.addAt(path, err.Error())
+		}
+	}
+
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		if v.errors[i].Line != v.errors[j].Line {
+			return v.errors[i].Line < v.errors[j].Line
+		}
+		return v.errors[i].Column < v.errors[j].Column
+	})
+	return v.errors
+}
+
+// validateSettings validates the top-level keys other than the jobs,
+// watches, and the configuration that has its own package, and rejects
+// any key that's not in the schema
+func (v *validator) validateSettings(configMap map[string]interface{}) {
+	var logConfig logger.Config
+	var stopTimeout int
+	var watchConfig bool
+	if err := decode.ToStruct(configMap["logging"], &logConfig); err != nil {
+		v.add("logging", err)
+	}
+	if err := decode.ToStruct(configMap["stopTimeout"], &stopTimeout); err != nil {
+		v.add("stopTimeout", err)
+	}
+	if err := decode.ToStruct(configMap["watchConfig"], &watchConfig); err != nil {
+		v.add("watchConfig", err)
+	}
+	for _, path := range configSchema.unknownKeys(configMap, "") {
+		v.addAt(path, "unknown config key")
+	}
+}
+
+var (
+	// matches the job or watch named in a validation error, and the path
+	// to the field within it (ex. "job[app].health.interval")
+	fieldPattern = regexp.MustCompile(`(?:job|watch)\[([^\]]*)\]((?:\.\w+|\[\d+\])*)`)
+
+	// matches the field quoted in an error decoding the configuration
+	// (ex. "cannot parse '[0].health.interval' as int")
+	decodeFieldPattern = regexp.MustCompile(`'([^']*)'`)
+)
+
+type validator struct {
+	data    []byte
+	offsets map[string]int
+	errors  []*ValidationError
+}
+
+// add records the error for the part of the configuration at the path.
+// An error decoding the configuration lists each field that couldn't be
+// decoded, and these are recorded as separate errors, except for unknown
+// keys, which the schema has already found.
+func (v *validator) add(path string, err error) {
+	msg := err.Error()
+	var decodeErrs []string
+	isDecodeErr := strings.Contains(msg, "error(s) decoding")
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(line, "* ") && !strings.Contains(line, "has invalid keys: ") {
+			decodeErrs = append(decodeErrs, strings.TrimPrefix(line, "* "))
+		}
+	}
+	if !isDecodeErr {
+		field := ""
+		if match := fieldPattern.FindStringSubmatch(msg); match != nil {
+			field = strings.TrimPrefix(match[2], ".")
+		}
+		v.addAt(joinPath(path, field), msg)
+		return
+	}
+	for _, decodeErr := range decodeErrs {
+		field := ""
+		if match := decodeFieldPattern.FindStringSubmatch(decodeErr); match != nil {
+			// jobs and watches are decoded one at a time as the
+			// only element of a slice
+			field = strings.TrimPrefix(match[1], "[0]")
+			field = strings.TrimPrefix(field, ".")
+			decodeErr = strings.Replace(decodeErr, "'"+match[1]+"'",
+				"'"+joinPath(path, field)+"'", 1)
+		}
+		v.addAt(joinPath(path, field), decodeErr)
+	}
+}
+
+// addAt records the error message for the part of the configuration at
+// the path, or its closest parent that we can find in the source
+func (v *validator) addAt(path, msg string) {
+	found := path
+	offset, ok := v.offsets[found]
+	for !ok && found != "" {
+		if i := strings.LastIndexAny(found, ".["); i >= 0 {
+			found = found[:i]
+		} else {
+			found = ""
+		}
+		offset, ok = v.offsets[found]
+	}
+	line, col, highlight := highlightError(v.data, int64(offset+1))
+	v.errors = append(v.errors, &ValidationError{
+		Path: path, Line: line, Column: col, Message: msg,
+		highlight: highlight,
+	})
+}
+
+func joinPath(path, field string) string {
+	switch {
+	case field == "":
+		return path
+	case path == "" || strings.HasPrefix(field, "["):
+		return path + field
+	default:
+		return path + "." + field
+	}
+}
+
+// sourceOffsets finds the offset where each key and array element starts
+// in the JSON5 data, by its JSON path (ex. "jobs[1].health.interval").
+// The data must have already been parsed without error, because this
+// doesn't check the syntax.
+func sourceOffsets(data []byte) map[string]int {
+	s := &offsetScanner{data: data, offsets: map[string]int{}}
+	s.value("")
+	return s.offsets
+}
+
+type offsetScanner struct {
+	data    []byte
+	pos     int
+	offsets map[string]int
+}
+
+func (s *offsetScanner) done() bool {
+	return s.pos >= len(s.data)
+}
+
+// skip moves past any whitespace and comments
+func (s *offsetScanner) skip() {
+	for !s.done() {
+		rest := s.data[s.pos:]
+		switch {
+		case isSpace(rest[0]):
+			s.pos++
+		case bytes.HasPrefix(rest, []byte("//")):
+			for !s.done() && s.data[s.pos] != '\n' {
+				s.pos++
+			}
+		case bytes.HasPrefix(rest, []byte("/*")):
+			end := bytes.Index(rest[2:], []byte("*/"))
+			if end < 0 {
+				s.pos = len(s.data)
+			} else {
+				s.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *offsetScanner) value(path string) {
+	s.skip()
+	if s.done() {
+		return
+	}
+	if _, ok := s.offsets[path]; !ok {
+		s.offsets[path] = s.pos
+	}
+	switch s.data[s.pos] {
+	case '{':
+		s.object(path)
+	case '[':
+		s.array(path)
+	case '"', '\'':
+		s.quoted()
+	default:
+		for !s.done() && !isSpace(s.data[s.pos]) &&
+			!strings.ContainsRune(",:]}/", rune(s.data[s.pos])) {
+			s.pos++
+		}
+	}
+}
+
+func (s *offsetScanner) object(path string) {
+	s.pos++ // opening brace
+	for {
+		s.skip()
+		if s.done() {
+			return
+		}
+		if s.data[s.pos] == '}' {
+			s.pos++
+			return
+		}
+		start := s.pos
+		var key string
+		if c := s.data[s.pos]; c == '"' || c == '\'' {
+			key = s.quoted()
+		} else {
+			for !s.done() && !isSpace(s.data[s.pos]) &&
+				!strings.ContainsRune(":/", rune(s.data[s.pos])) {
+				s.pos++
+			}
+			key = string(s.data[start:s.pos])
+		}
+		s.skip()
+		if !s.done() && s.data[s.pos] == ':' {
+			s.pos++
+		}
+		field := joinPath(path, key)
+		s.offsets[field] = start
+		s.value(field)
+		if !s.next(start) {
+			return
+		}
+	}
+}
+
+func (s *offsetScanner) array(path string) {
+	s.pos++ // opening bracket
+	for i := 0; ; i++ {
+		s.skip()
+		if s.done() {
+			return
+		}
+		if s.data[s.pos] == ']' {
+			s.pos++
+			return
+		}
+		start := s.pos
+		s.value(fmt.Sprintf("%s[%d]", path, i))
+		if !s.next(start) {
+			return
+		}
+	}
+}
+
+// next moves past the comma after a key or element, and returns false if
+// the scanner hasn't moved since the start of the key or element, so that
+// unexpected input can't loop forever
+func (s *offsetScanner) next(start int) bool {
+	s.skip()
+	if !s.done() && s.data[s.pos] == ',' {
+		s.pos++
+	}
+	return s.pos > start
+}
+
+// quoted moves past a quoted string and returns its contents
+func (s *offsetScanner) quoted() string {
+	quote := s.data[s.pos]
+	s.pos++
+	start := s.pos
+	for !s.done() && s.data[s.pos] != quote {
+		if s.data[s.pos] == '\\' {
+			s.pos++
+		}
+		s.pos++
+	}
+	end := s.pos
+	if end > len(s.data) {
+		end = len(s.data)
+	}
+	s.pos++
+	return string(s.data[start:end])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
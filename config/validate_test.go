@@ -0,0 +1,104 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	errs := validateConfig([]byte(`{
+  consul: "consul:8500",
+  bogus: true,
+  jobs: [
+    {
+      name: "app",
+      exec: "/bin/app",
+      port: "abc",
+    },
+    {
+      name: "db",
+      exec: "/bin/db",
+      extra: 1
+    },
+  ],
+  watches: [
+    { name: "upstream", interval: 0 }
+  ],
+  telemetry: { port: "x" }
+}`))
+	type location struct {
+		path      string
+		line, col int
+	}
+	got := []location{}
+	for _, err := range errs {
+		got = append(got, location{err.Path, err.Line, err.Column})
+	}
+	assert.Equal(t, []location{
+		{"bogus", 3, 3},
+		{"jobs[0].port", 8, 7},
+		{"jobs[1].extra", 13, 7},
+		{"watches[0].interval", 17, 25},
+		{"telemetry.port", 19, 16},
+	}, got)
+	assert.Equal(t, "unknown config key", errs[2].Message)
+	assert.Equal(t, "watch[upstream].interval must be > 0", errs[3].Message)
+}
+
+func TestValidateConfigGraph(t *testing.T) {
+	errs := validateConfig([]byte(`{
+  consul: "consul:8500",
+  jobs: [
+    { name: "app", exec: "/bin/app" },
+    { name: "db", exec: "/bin/db", when: { source: "nope" } },
+  ]
+}`))
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error but got %v", errs)
+	}
+	assert.Equal(t, "jobs[1].when.source", errs[0].Path)
+	assert.Equal(t, 5, errs[0].Line)
+	assert.Equal(t, 44, errs[0].Column)
+}
+
+func TestValidateConfigParseError(t *testing.T) {
+	errs := validateConfig([]byte("{\n  consul: \"consul:8500\"\n  jobs: []\n}"))
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error but got %v", errs)
+	}
+	assert.Equal(t, "", errs[0].Path)
+	assert.Equal(t, 3, errs[0].Line)
+	assert.Equal(t, 3, errs[0].Column)
+}
+
+func TestValidateConfigValid(t *testing.T) {
+	errs := validateConfig([]byte(`{consul: "consul:8500", jobs: [{name: "app", exec: "/bin/app"}]}`))
+	assert.Empty(t, errs)
+}
+
+func TestSourceOffsets(t *testing.T) {
+	data := []byte(`{
+  // jobs: "commented out"
+  "jobs": [
+    /* first */ { 'name': "a\"b", exec: ["x", "y"] },
+    { name: "c" }
+  ],
+}`)
+	offsets := sourceOffsets(data)
+	for path, expected := range map[string]string{
+		"":             "{",
+		"jobs":         `"jobs"`,
+		"jobs[0]":      "{ 'name'",
+		"jobs[0].name": "'name'",
+		"jobs[0].exec": "exec",
+		"jobs[1].name": `name: "c"`,
+	} {
+		offset, ok := offsets[path]
+		if !ok {
+			t.Errorf("no offset for %q", path)
+			continue
+		}
+		assert.Equal(t, expected, string(data[offset:offset+len(expected)]), path)
+	}
+}
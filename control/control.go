@@ -38,6 +38,9 @@ func init() {
 type HTTPServer struct {
 	Addr string
 	Bus  *events.EventBus
+	Jobs JobRegistry
+
+	endpoints *Endpoints
 
 	http.Server
 	events.Publisher
@@ -91,7 +94,9 @@ func (srv *HTTPServer) Start(cancel context.CancelFunc) {
 	endpoints := &Endpoints{
 		bus:    srv.Publisher.Bus,
 		cancel: cancel,
+		jobs:   srv.Jobs,
 	}
+	srv.endpoints = endpoints
 
 	router := http.NewServeMux()
 	router.Handle("/v3/environ",
@@ -104,6 +109,12 @@ func (srv *HTTPServer) Start(cancel context.CancelFunc) {
 		PostHandler(endpoints.PostEnableMaintenanceMode))
 	router.Handle("/v3/maintenance/disable",
 		PostHandler(endpoints.PostDisableMaintenanceMode))
+	router.Handle("/v3/jobs",
+		PostHandler(endpoints.PostJobs))
+	router.Handle("/v3/jobs/", MethodHandler{
+		http.MethodPost:   PostHandler(endpoints.PostJob),
+		http.MethodDelete: DeleteHandler(endpoints.DeleteJob),
+	})
 	router.HandleFunc("/v3/ping", GetPing)
 
 	srv.Handler = router
@@ -119,6 +130,17 @@ func (srv *HTTPServer) Start(cancel context.CancelFunc) {
 	}()
 }
 
+// Reload reloads ContainerPilot's configuration just as a request to the
+// reload endpoint does, for reloads that don't come through the control
+// plane. The server must be running.
+func (srv *HTTPServer) Reload() error {
+	if srv.endpoints == nil {
+		return errors.New("control server is not running")
+	}
+	_, err := srv.endpoints.reload()
+	return err
+}
+
 // on a reload we can't guarantee that the control server will be shut down and
 // the socket file cleaned up before we're ready to start again, so we'll retry
 // with the listener a few times before bailing out.
@@ -9,7 +9,10 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/joyent/containerpilot/config/timing"
 	"github.com/joyent/containerpilot/events"
 	log "github.com/sirupsen/logrus"
 )
@@ -19,6 +22,33 @@ import (
 type Endpoints struct {
 	bus    *events.EventBus
 	cancel context.CancelFunc
+	jobs   JobRegistry
+}
+
+// JobRegistry is implemented by the App so that the control endpoints
+// can check that a job exists before acting on it, can pass along the
+// details of a request for maintenance mode that don't fit in an Event,
+// and can add or remove jobs without reloading
+type JobRegistry interface {
+	HasJob(name string) bool
+	// RequestMaintenance sets the reason and TTL for the named job (or
+	// all jobs if the name is empty) to use when it enters maintenance
+	RequestMaintenance(name, reason string, ttl time.Duration)
+	// AddJob validates the job config and runs the new job
+	AddJob(raw map[string]interface{}) error
+	// RemoveJob stops the named job and returns false if there's no such job
+	RemoveJob(name string) bool
+	// Reload applies the changes in the configuration file to the running
+	// jobs and watches and returns the differences, or returns true if
+	// everything has to be reloaded instead
+	Reload() (diff interface{}, restart bool, err error)
+}
+
+// maintenanceRequest is the optional JSON body of a request to enable
+// maintenance mode
+type maintenanceRequest struct {
+	Reason string `json:"reason"`
+	TTL    string `json:"ttl"`
 }
 
 // PostHandler is an adapter which allows a normal function to serve itself and
@@ -27,14 +57,39 @@ type Endpoints struct {
 type PostHandler func(*http.Request) (interface{}, int)
 
 func (pw PostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		failedStatus := http.StatusMethodNotAllowed
-		http.Error(w, http.StatusText(failedStatus), failedStatus)
-		collector.WithLabelValues(
-			strconv.Itoa(http.StatusMethodNotAllowed), r.URL.Path).Inc()
+	serveMethod(w, r, http.MethodPost, pw)
+}
+
+// DeleteHandler is the same adapter as PostHandler for HTTP DELETE requests
+type DeleteHandler func(*http.Request) (interface{}, int)
+
+func (dh DeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveMethod(w, r, http.MethodDelete, dh)
+}
+
+// MethodHandler routes requests for the same path to a handler for each
+// HTTP method, so that the path can accept both POST and DELETE requests
+type MethodHandler map[string]http.Handler
+
+func (mh MethodHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, ok := mh[r.Method]
+	if !ok {
+		methodNotAllowed(w, r)
 		return
 	}
-	resp, status := pw(r)
+	handler.ServeHTTP(w, r)
+}
+
+// serveMethod writes the response from the handler for requests with the
+// given method. If the handler fails with a string response, it's used
+// as the error message in place of the status text.
+func serveMethod(w http.ResponseWriter, r *http.Request, method string,
+	handler func(*http.Request) (interface{}, int)) {
+	if r.Method != method {
+		methodNotAllowed(w, r)
+		return
+	}
+	resp, status := handler(r)
 	switch status {
 	case http.StatusOK:
 		if resp != nil {
@@ -46,11 +101,22 @@ func (pw PostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			io.WriteString(w, "\n")
 		}
 	default:
-		http.Error(w, http.StatusText(status), status)
+		if msg, ok := resp.(string); ok {
+			http.Error(w, msg, status)
+		} else {
+			http.Error(w, http.StatusText(status), status)
+		}
 	}
 	collector.WithLabelValues(strconv.Itoa(status), r.URL.Path).Inc()
 }
 
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	failedStatus := http.StatusMethodNotAllowed
+	http.Error(w, http.StatusText(failedStatus), failedStatus)
+	collector.WithLabelValues(
+		strconv.Itoa(http.StatusMethodNotAllowed), r.URL.Path).Inc()
+}
+
 // PutEnviron handles incoming HTTP POST requests containing JSON environment
 // variables and updates the environment of our current ContainerPilot
 // process. Returns empty response or HTTP422.
@@ -72,29 +138,87 @@ func (e Endpoints) PutEnviron(r *http.Request) (interface{}, int) {
 }
 
 // PostReload handles incoming HTTP POST requests and reloads our current
-// ContainerPilot process configuration.  Returns empty response or HTTP422.
+// ContainerPilot process configuration. Only the jobs and watches that
+// changed are restarted, unless something else in the configuration
+// changed. Returns the differences in the configuration, or HTTP422 with
+// the error if the new configuration isn't valid.
 func (e Endpoints) PostReload(r *http.Request) (interface{}, int) {
-	defer e.cancel()
 	log.Debug("control: reloading app via control plane")
 	if r.Body != nil {
 		defer r.Body.Close()
 	}
+	diff, err := e.reload()
+	if err != nil {
+		return err.Error(), http.StatusUnprocessableEntity
+	}
+	log.Debug("control: reloaded app via control plane")
+	return diff, http.StatusOK
+}
+
+// reload does the work of reloading for PostReload and HTTPServer.Reload,
+// and returns the differences in the configuration
+func (e Endpoints) reload() (interface{}, error) {
+	var diff interface{}
+	if e.jobs != nil {
+		var (
+			restart bool
+			err     error
+		)
+		diff, restart, err = e.jobs.Reload()
+		if err != nil {
+			return nil, err
+		}
+		if !restart {
+			return diff, nil
+		}
+	}
+	defer e.cancel()
 	e.bus.SetReloadFlag()
 	e.bus.Shutdown()
-	log.Debug("control: reloaded app via control plane")
-	return nil, http.StatusOK
+	return diff, nil
 }
 
 // PostEnableMaintenanceMode handles incoming HTTP POST requests and toggles
-// ContainerPilot maintenance mode on. Returns empty response or HTTP422.
+// ContainerPilot maintenance mode on. The request may have a JSON body
+// with the reason for maintenance and a TTL after which it expires.
+// Returns empty response or HTTP422.
 func (e Endpoints) PostEnableMaintenanceMode(r *http.Request) (interface{}, int) {
-	if r.Body != nil {
-		defer r.Body.Close()
+	if status := e.requestMaintenance(r, ""); status != http.StatusOK {
+		return nil, status
 	}
 	e.bus.Publish(events.GlobalEnterMaintenance)
 	return nil, http.StatusOK
 }
 
+// requestMaintenance parses the optional body of a request to enable
+// maintenance mode and passes it along to the job (or all jobs). Returns
+// HTTP422 if the body can't be parsed.
+func (e Endpoints) requestMaintenance(r *http.Request, name string) int {
+	var req maintenanceRequest
+	if r.Body != nil {
+		defer r.Body.Close()
+		jsonBlob, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return http.StatusUnprocessableEntity
+		}
+		if len(strings.TrimSpace(string(jsonBlob))) > 0 {
+			if err := json.Unmarshal(jsonBlob, &req); err != nil {
+				log.Debug(err)
+				return http.StatusUnprocessableEntity
+			}
+		}
+	}
+	ttl, err := timing.GetTimeout(req.TTL)
+	if err != nil || ttl < 0 {
+		log.Debugf("control: invalid maintenance ttl '%s'", req.TTL)
+		return http.StatusUnprocessableEntity
+	}
+	if e.jobs != nil {
+		e.jobs.RequestMaintenance(name, req.Reason, ttl)
+	}
+	return http.StatusOK
+}
+
 // PostDisableMaintenanceMode handles incoming HTTP POST requests and toggles
 // ContainerPilot maintenance mode on. Returns empty response or HTTP422.
 func (e Endpoints) PostDisableMaintenanceMode(r *http.Request) (interface{}, int) {
@@ -105,6 +229,89 @@ func (e Endpoints) PostDisableMaintenanceMode(r *http.Request) (interface{}, int
 	return nil, http.StatusOK
 }
 
+// PostJob handles incoming HTTP POST requests to '/v3/jobs/{name}/{action}'
+// and publishes the event for the action to the named job. The actions are
+// 'start', 'stop', 'restart', 'maintenance/enable', and
+// 'maintenance/disable'. Returns empty response, HTTP404 if there's no
+// such job or action, or HTTP422 if a maintenance request can't be parsed.
+func (e Endpoints) PostJob(r *http.Request) (interface{}, int) {
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/v3/jobs/"), "/", 2)
+	if len(parts) != 2 || e.jobs == nil || !e.jobs.HasJob(parts[0]) {
+		return nil, http.StatusNotFound
+	}
+	name, action := parts[0], parts[1]
+	var code events.EventCode
+	switch action {
+	case "start":
+		code = events.Start
+	case "stop":
+		code = events.Stop
+	case "restart":
+		code = events.Restart
+	case "maintenance/enable":
+		if status := e.requestMaintenance(r, name); status != http.StatusOK {
+			return nil, status
+		}
+		code = events.EnterMaintenance
+	case "maintenance/disable":
+		code = events.ExitMaintenance
+	default:
+		return nil, http.StatusNotFound
+	}
+	log.Debugf("control: %s job[%s] via control plane", action, name)
+	e.bus.Publish(events.Event{Code: code, Source: name})
+	return nil, http.StatusOK
+}
+
+// PostJobs handles incoming HTTP POST requests to '/v3/jobs' containing
+// the JSON config for a new job, and runs the job without reloading.
+// Returns empty response, HTTP409 if there's already a job with that
+// name, or HTTP422 with the error if the config isn't valid.
+func (e Endpoints) PostJobs(r *http.Request) (interface{}, int) {
+	var raw map[string]interface{}
+	if r.Body == nil {
+		return nil, http.StatusUnprocessableEntity
+	}
+	jsonBlob, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity
+	}
+	if err := json.Unmarshal(jsonBlob, &raw); err != nil {
+		log.Debug(err)
+		return nil, http.StatusUnprocessableEntity
+	}
+	if e.jobs == nil {
+		return nil, http.StatusNotFound
+	}
+	if name, ok := raw["name"].(string); ok && e.jobs.HasJob(name) {
+		return nil, http.StatusConflict
+	}
+	if err := e.jobs.AddJob(raw); err != nil {
+		log.Errorf("control: unable to add job: %v", err)
+		return err.Error(), http.StatusUnprocessableEntity
+	}
+	return nil, http.StatusOK
+}
+
+// DeleteJob handles incoming HTTP DELETE requests to '/v3/jobs/{name}'
+// and stops and removes the named job without reloading. Returns empty
+// response or HTTP404 if there's no such job.
+func (e Endpoints) DeleteJob(r *http.Request) (interface{}, int) {
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/v3/jobs/")
+	if name == "" || strings.Contains(name, "/") ||
+		e.jobs == nil || !e.jobs.RemoveJob(name) {
+		return nil, http.StatusNotFound
+	}
+	return nil, http.StatusOK
+}
+
 // PostMetric handles incoming HTTP POST requests, serializes the metrics
 // into Events, and publishes them for sensors to record their values.
 // Returns empty response or HTTP422.
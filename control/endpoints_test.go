@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -99,6 +100,16 @@ func TestPostHandler(t *testing.T) {
 		assert.Equal(t, 405, status, "expected HTTP405 method not allowed")
 		assert.Equal(t, "Method Not Allowed\n", result)
 	})
+
+	t.Run("POST error message", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v3/foo", nil)
+		status, result := testFunc(req,
+			func(r *http.Request) (interface{}, int) {
+				return "bad foo", 422
+			})
+		assert.Equal(t, 422, status, "expected HTTP422")
+		assert.Equal(t, "bad foo\n", result)
+	})
 }
 
 func TestPostMetric(t *testing.T) {
@@ -168,6 +179,20 @@ func TestPostEnableMaintenanceMode(t *testing.T) {
 	t.Run("POST bad JSON", func(t *testing.T) {
 		body := "{{\n"
 		req, _ := http.NewRequest("POST", "/v3/maintenance/enable", strings.NewReader(body))
+		expected := map[events.Event]int{}
+		status := testFunc(t, expected, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, "status was not 422")
+	})
+	t.Run("POST bad TTL", func(t *testing.T) {
+		body := `{"ttl": "x"}`
+		req, _ := http.NewRequest("POST", "/v3/maintenance/enable", strings.NewReader(body))
+		expected := map[events.Event]int{}
+		status := testFunc(t, expected, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, "status was not 422")
+	})
+	t.Run("POST reason", func(t *testing.T) {
+		body := `{"reason": "upgrading", "ttl": "10m"}`
+		req, _ := http.NewRequest("POST", "/v3/maintenance/enable", strings.NewReader(body))
 		expected := map[events.Event]int{events.GlobalEnterMaintenance: 1}
 		status := testFunc(t, expected, req)
 		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
@@ -226,3 +251,233 @@ func TestGetPing(t *testing.T) {
 	status := resp.StatusCode
 	assert.Equal(t, 200, status, "expected HTTP 200 OK")
 }
+
+type testJobs []string
+
+func (jobs testJobs) HasJob(name string) bool {
+	for _, job := range jobs {
+		if job == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (jobs testJobs) RequestMaintenance(name, reason string, ttl time.Duration) {}
+
+func (jobs testJobs) AddJob(raw map[string]interface{}) error {
+	if raw["exec"] == nil {
+		return fmt.Errorf("job[%v] exec is required", raw["name"])
+	}
+	return nil
+}
+
+func (jobs testJobs) RemoveJob(name string) bool {
+	return jobs.HasJob(name)
+}
+
+func (jobs testJobs) Reload() (interface{}, bool, error) {
+	return map[string][]string{"changed": jobs}, false, nil
+}
+
+// reloadRegistry is a JobRegistry whose Reload returns a fixed result
+type reloadRegistry struct {
+	testJobs
+	restart bool
+	err     error
+}
+
+func (r reloadRegistry) Reload() (interface{}, bool, error) {
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	diff, _, _ := r.testJobs.Reload()
+	return diff, r.restart, nil
+}
+
+// maintenanceRecorder is a JobRegistry that records maintenance requests
+type maintenanceRecorder struct {
+	testJobs
+	name, reason string
+	ttl          time.Duration
+}
+
+func (r *maintenanceRecorder) RequestMaintenance(name, reason string, ttl time.Duration) {
+	r.name, r.reason, r.ttl = name, reason, ttl
+}
+
+func TestPostJob(t *testing.T) {
+	testFunc := func(t *testing.T, expected map[events.Event]int, path string) int {
+		_, cancel := context.WithCancel(context.Background())
+		bus := events.NewEventBus()
+		bus.Publish(events.GlobalStartup)
+		endpoints := &Endpoints{
+			bus:    bus,
+			cancel: cancel,
+			jobs:   testJobs{"myjob"},
+		}
+		req, _ := http.NewRequest("POST", path, nil)
+		_, status := endpoints.PostJob(req)
+		results := bus.DebugEvents()
+		got := map[events.Event]int{}
+		for _, result := range results {
+			if result != events.GlobalStartup {
+				got[result]++
+			}
+		}
+		assert.Equal(t, expected, got)
+		return status
+	}
+
+	t.Run("POST start", func(t *testing.T) {
+		expected := map[events.Event]int{{events.Start, "myjob"}: 1}
+		status := testFunc(t, expected, "/v3/jobs/myjob/start")
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+	})
+	t.Run("POST stop", func(t *testing.T) {
+		expected := map[events.Event]int{{events.Stop, "myjob"}: 1}
+		status := testFunc(t, expected, "/v3/jobs/myjob/stop")
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+	})
+	t.Run("POST restart", func(t *testing.T) {
+		expected := map[events.Event]int{{events.Restart, "myjob"}: 1}
+		status := testFunc(t, expected, "/v3/jobs/myjob/restart")
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+	})
+	t.Run("POST maintenance enable", func(t *testing.T) {
+		expected := map[events.Event]int{{events.EnterMaintenance, "myjob"}: 1}
+		status := testFunc(t, expected, "/v3/jobs/myjob/maintenance/enable")
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+	})
+	t.Run("POST maintenance disable", func(t *testing.T) {
+		expected := map[events.Event]int{{events.ExitMaintenance, "myjob"}: 1}
+		status := testFunc(t, expected, "/v3/jobs/myjob/maintenance/disable")
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+	})
+	t.Run("POST maintenance enable with reason", func(t *testing.T) {
+		registry := &maintenanceRecorder{testJobs: testJobs{"myjob"}}
+		endpoints := &Endpoints{bus: events.NewEventBus(), jobs: registry}
+		req, _ := http.NewRequest("POST", "/v3/jobs/myjob/maintenance/enable",
+			strings.NewReader(`{"reason": "draining", "ttl": "90s"}`))
+		_, status := endpoints.PostJob(req)
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+		assert.Equal(t, "myjob", registry.name)
+		assert.Equal(t, "draining", registry.reason)
+		assert.Equal(t, 90*time.Second, registry.ttl)
+	})
+	t.Run("POST unknown job", func(t *testing.T) {
+		status := testFunc(t, map[events.Event]int{}, "/v3/jobs/other/start")
+		assert.Equal(t, http.StatusNotFound, status, "status was not 404")
+	})
+	t.Run("POST unknown action", func(t *testing.T) {
+		status := testFunc(t, map[events.Event]int{}, "/v3/jobs/myjob/pause")
+		assert.Equal(t, http.StatusNotFound, status, "status was not 404")
+	})
+}
+
+func TestPostJobs(t *testing.T) {
+	testFunc := func(t *testing.T, body string) (int, string) {
+		endpoints := &Endpoints{bus: events.NewEventBus(), jobs: testJobs{"myjob"}}
+		req := httptest.NewRequest("POST", "/v3/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		PostHandler(endpoints.PostJobs).ServeHTTP(w, req)
+		resp := w.Result()
+		defer resp.Body.Close()
+		result, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(result)
+	}
+	t.Run("POST ok", func(t *testing.T) {
+		status, _ := testFunc(t, `{"name": "debug", "exec": "sleep 60"}`)
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+	})
+	t.Run("POST bad JSON", func(t *testing.T) {
+		status, _ := testFunc(t, "{{\n")
+		assert.Equal(t, http.StatusUnprocessableEntity, status, "status was not 422")
+	})
+	t.Run("POST existing job", func(t *testing.T) {
+		status, _ := testFunc(t, `{"name": "myjob", "exec": "sleep 60"}`)
+		assert.Equal(t, http.StatusConflict, status, "status was not 409")
+	})
+	t.Run("POST no body", func(t *testing.T) {
+		endpoints := &Endpoints{bus: events.NewEventBus(), jobs: testJobs{"myjob"}}
+		_, status := endpoints.PostJobs(&http.Request{Method: http.MethodPost})
+		assert.Equal(t, http.StatusUnprocessableEntity, status, "status was not 422")
+	})
+	t.Run("POST invalid job", func(t *testing.T) {
+		status, result := testFunc(t, `{"name": "debug"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, "status was not 422")
+		assert.Equal(t, "job[debug] exec is required\n", result)
+	})
+}
+
+func TestDeleteJob(t *testing.T) {
+	testFunc := func(t *testing.T, method, path string) int {
+		endpoints := &Endpoints{bus: events.NewEventBus(), jobs: testJobs{"myjob"}}
+		handler := MethodHandler{
+			http.MethodPost:   PostHandler(endpoints.PostJob),
+			http.MethodDelete: DeleteHandler(endpoints.DeleteJob),
+		}
+		req := httptest.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+	t.Run("DELETE ok", func(t *testing.T) {
+		status := testFunc(t, "DELETE", "/v3/jobs/myjob")
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+	})
+	t.Run("DELETE unknown job", func(t *testing.T) {
+		status := testFunc(t, "DELETE", "/v3/jobs/other")
+		assert.Equal(t, http.StatusNotFound, status, "status was not 404")
+	})
+	t.Run("DELETE job action", func(t *testing.T) {
+		status := testFunc(t, "DELETE", "/v3/jobs/myjob/start")
+		assert.Equal(t, http.StatusNotFound, status, "status was not 404")
+	})
+	t.Run("GET bad method", func(t *testing.T) {
+		status := testFunc(t, "GET", "/v3/jobs/myjob")
+		assert.Equal(t, http.StatusMethodNotAllowed, status, "status was not 405")
+	})
+}
+
+func TestPostReload(t *testing.T) {
+	testFunc := func(t *testing.T, registry JobRegistry,
+		expected map[events.Event]int) (int, string) {
+		_, cancel := context.WithCancel(context.Background())
+		bus := events.NewEventBus()
+		bus.Publish(events.GlobalStartup)
+		endpoints := &Endpoints{bus: bus, cancel: cancel, jobs: registry}
+		req := httptest.NewRequest("POST", "/v3/reload", nil)
+		w := httptest.NewRecorder()
+		PostHandler(endpoints.PostReload).ServeHTTP(w, req)
+		resp := w.Result()
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		got := map[events.Event]int{}
+		for _, result := range bus.DebugEvents() {
+			if result != events.GlobalStartup {
+				got[result]++
+			}
+		}
+		assert.Equal(t, expected, got)
+		return resp.StatusCode, string(body)
+	}
+	t.Run("changed jobs", func(t *testing.T) {
+		status, body := testFunc(t, testJobs{"myjob"}, map[events.Event]int{})
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+		assert.Equal(t, "{\"changed\":[\"myjob\"]}\n", body)
+	})
+	t.Run("restart", func(t *testing.T) {
+		registry := reloadRegistry{testJobs: testJobs{"myjob"}, restart: true}
+		status, body := testFunc(t, registry,
+			map[events.Event]int{events.GlobalShutdown: 1})
+		assert.Equal(t, http.StatusOK, status, "status was not 200OK")
+		assert.Equal(t, "{\"changed\":[\"myjob\"]}\n", body)
+	})
+	t.Run("invalid config", func(t *testing.T) {
+		registry := reloadRegistry{err: fmt.Errorf("unable to parse jobs")}
+		status, body := testFunc(t, registry, map[events.Event]int{})
+		assert.Equal(t, http.StatusUnprocessableEntity, status, "status was not 422")
+		assert.Equal(t, "unable to parse jobs\n", body)
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/joyent/containerpilot/commands"
 	"github.com/joyent/containerpilot/config"
 	"github.com/joyent/containerpilot/control"
 	"github.com/joyent/containerpilot/discovery"
@@ -32,12 +33,36 @@ type App struct {
 	signalLock    *sync.RWMutex
 	ConfigFlag    string
 	Bus           *events.EventBus
+
+	// the config the jobs and watches were created from, for finding
+	// the changes on reload
+	cfg *config.Config
+
+	// jobs can be added or removed through the control plane while
+	// they're running, so we need the context and completion channel
+	// the rest were run with
+	jobsLock    *sync.RWMutex
+	runCtx      context.Context
+	completedCh chan struct{}
+
+	// changes to the set of jobs (reloads, and jobs added or removed
+	// through the control plane) are made one at a time. These wait for
+	// the jobs they stop without holding the jobsLock.
+	changeLock *sync.Mutex
+
+	// the clock the jobs and watches run on, and the stubs that stand in
+	// for the jobs' processes, which are only set for a simulation
+	clock events.Clock
+	stubs commands.Stubs
 }
 
 // EmptyApp creates an empty application
 func EmptyApp() *App {
 	app := &App{}
 	app.signalLock = &sync.RWMutex{}
+	app.jobsLock = &sync.RWMutex{}
+	app.changeLock = &sync.Mutex{}
+	app.clock = events.WallClock{}
 	return app
 }
 
@@ -69,25 +94,30 @@ func NewApp(configFlag string) (*App, error) {
 
 	a.StopTimeout = cfg.StopTimeout
 	a.Discovery = cfg.Discovery
-	a.Jobs = jobs.FromConfigs(cfg.Jobs)
-	a.Watches = watches.FromConfigs(cfg.Watches)
+	a.Jobs = a.newJobs(cfg.Jobs)
+	a.Watches = a.newWatches(cfg.Watches)
 	a.Telemetry = telemetry.NewTelemetry(cfg.Telemetry)
 	a.Telemetry.MonitorJobs(a.Jobs)
 	a.Telemetry.MonitorWatches(a.Watches)
 	a.ConfigFlag = configFlag // stash the old config
+	a.cfg = cfg
 
 	// set an environment variable for each job IP address so that
 	// forked processes have access to this information
 	for _, job := range a.Jobs {
-		if job.Service != nil {
-			envKey := getEnvVarNameFromService(job.Name)
-			os.Setenv(envKey, job.Service.IPAddress)
-		}
+		setJobIPEnv(job)
 	}
 
 	return a, nil
 }
 
+func setJobIPEnv(job *jobs.Job) {
+	if job.Service != nil {
+		envKey := getEnvVarNameFromService(job.Name)
+		os.Setenv(envKey, job.Service.IPAddress)
+	}
+}
+
 // Normalize the validated service name as an environment variable
 func getEnvVarNameFromService(service string) string {
 	envKey := strings.ToUpper(service)
@@ -123,12 +153,18 @@ func (a *App) Run() {
 			for {
 				select {
 				case <-completedCh:
+					// a job that completes because it's being replaced
+					// doesn't count until the change is made
 					quit := true
+					a.changeLock.Lock()
+					a.jobsLock.RLock()
 					for _, job := range a.Jobs {
 						if !job.IsComplete {
 							quit = false
 						}
 					}
+					a.jobsLock.RUnlock()
+					a.changeLock.Unlock()
 					if quit {
 						cancel()
 						return
@@ -140,10 +176,20 @@ func (a *App) Run() {
 		}()
 
 		a.Bus = events.NewEventBus()
+		a.ControlServer.Jobs = a
 		a.ControlServer.Run(ctx, a.Bus)
 		a.runTasks(ctx, completedCh)
 
-		if !a.Bus.Wait() {
+		// the config file is watched until the App shuts down or reloads
+		// everything, after which the new config decides whether to watch
+		watchCtx, stopWatching := context.WithCancel(ctx)
+		if a.cfg != nil && a.cfg.WatchConfig {
+			a.watchConfigFile(watchCtx)
+		}
+
+		waited := a.Bus.Wait()
+		stopWatching()
+		if !waited {
 			if a.StopTimeout > 0 {
 				log.Debugf("killing all processes in %v seconds", a.StopTimeout)
 				tick := time.NewTimer(time.Duration(a.StopTimeout) * time.Second)
@@ -177,6 +223,133 @@ func (a *App) SignalEvent(sig string) {
 	a.Bus.PublishSignal(sig)
 }
 
+// HasJob returns true if the App has a job with the given name
+func (a *App) HasJob(name string) bool {
+	a.jobsLock.RLock()
+	defer a.jobsLock.RUnlock()
+	return a.hasJob(name)
+}
+
+func (a *App) hasJob(name string) bool {
+	for _, job := range a.Jobs {
+		if job.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddJob validates the raw job config, just as the jobs of the
+// configuration file are validated against each other and the watches,
+// and runs the new job on the live EventBus alongside the App's other
+// jobs. A job that starts on the
+// global startup event is started right away; any other job waits for
+// the next event it's waiting on.
+func (a *App) AddJob(raw map[string]interface{}) error {
+	if err := config.CheckJobKeys(raw); err != nil {
+		return err
+	}
+	cfg, err := jobs.NewConfig(raw, a.Discovery)
+	if err != nil {
+		return err
+	}
+	a.changeLock.Lock()
+	defer a.changeLock.Unlock()
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	if a.hasJob(cfg.Name) {
+		return fmt.Errorf("job[%s] already exists", cfg.Name)
+	}
+	if a.runCtx == nil {
+		return fmt.Errorf("job[%s] can't be added before jobs are running", cfg.Name)
+	}
+	watchNames := []string{}
+	for _, watch := range a.Watches {
+		watchNames = append(watchNames, watch.Name)
+	}
+	if err := jobs.ValidateAdded(cfg, a.Jobs, watchNames); err != nil {
+		return err
+	}
+	a.Jobs = append(a.Jobs, a.startJobs([]*jobs.Config{cfg})...)
+	log.Infof("job[%s] added through control plane", cfg.Name)
+	return nil
+}
+
+// startJobs creates and runs jobs alongside the App's running jobs. The
+// startup event has already been published to the other jobs, so it's
+// sent to each of the new jobs. The caller must hold the jobsLock.
+func (a *App) startJobs(cfgs []*jobs.Config) []*jobs.Job {
+	newJobs := a.newJobs(cfgs)
+	// subscribe all the new jobs before running any of them, for the
+	// same reason as in runTasks
+	for _, job := range newJobs {
+		setJobIPEnv(job)
+		job.Subscribe(a.Bus)
+		job.Register(a.Bus)
+	}
+	for _, job := range newJobs {
+		job.Run(a.runCtx, a.completedCh)
+		job.Receive(events.GlobalStartup)
+	}
+	a.Telemetry.MonitorJobs(newJobs)
+	return newJobs
+}
+
+// newJobs creates Jobs from validated configs on the App's clock
+func (a *App) newJobs(cfgs []*jobs.Config) []*jobs.Job {
+	newJobs := []*jobs.Job{}
+	for _, cfg := range cfgs {
+		newJobs = append(newJobs, jobs.NewSimulatedJob(cfg, a.clock, a.stubs))
+	}
+	return newJobs
+}
+
+// newWatches creates Watches from validated configs on the App's clock
+func (a *App) newWatches(cfgs []*watches.Config) []*watches.Watch {
+	newWatches := []*watches.Watch{}
+	for _, cfg := range cfgs {
+		newWatches = append(newWatches, watches.NewSimulatedWatch(cfg, a.clock))
+	}
+	return newWatches
+}
+
+// RemoveJob stops the named job and removes it from the App, returning
+// false if there's no such job. The job's exec is terminated and its
+// service deregistered from Consul, just as when ContainerPilot shuts down,
+// and RemoveJob waits for the job to stop.
+func (a *App) RemoveJob(name string) bool {
+	a.changeLock.Lock()
+	defer a.changeLock.Unlock()
+	a.jobsLock.Lock()
+	var removed *jobs.Job
+	for i, job := range a.Jobs {
+		if job.Name == name {
+			removed = job
+			a.Jobs = append(a.Jobs[:i], a.Jobs[i+1:]...)
+			break
+		}
+	}
+	a.jobsLock.Unlock()
+	if removed == nil {
+		return false
+	}
+	log.Infof("job[%s] removed through control plane", name)
+	a.stopJobs([]*jobs.Job{removed})
+	return true
+}
+
+// RequestMaintenance sets the reason and TTL that the named job (or all
+// jobs, if the name is empty) will use when it next enters maintenance
+func (a *App) RequestMaintenance(name, reason string, ttl time.Duration) {
+	a.jobsLock.RLock()
+	defer a.jobsLock.RUnlock()
+	for _, job := range a.Jobs {
+		if name == "" || job.Name == name {
+			job.RequestMaintenance(reason, ttl)
+		}
+	}
+}
+
 // reload does the actual work of reloading the configuration and
 // updating the App with those changes. The EventBus should be
 // already shut down before we call this.
@@ -192,12 +365,17 @@ func (a *App) reload() error {
 	a.StopTimeout = newApp.StopTimeout
 	a.Telemetry = newApp.Telemetry
 	a.ControlServer = newApp.ControlServer
+	a.cfg = newApp.cfg
 	return nil
 }
 
 // HandlePolling sets up polling functions and write their quit channels
 // back to our config
 func (a *App) runTasks(ctx context.Context, completedCh chan struct{}) {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	a.runCtx, a.completedCh = ctx, completedCh
+
 	// we need to subscribe to events before we Run all the jobs
 	// to avoid races where a job finishes and fires events before
 	// other jobs are even subscribed to listen for them.
@@ -1,14 +1,17 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/joyent/containerpilot/config"
 	"github.com/joyent/containerpilot/discovery"
 	"github.com/joyent/containerpilot/events"
 	"github.com/joyent/containerpilot/jobs"
@@ -146,6 +149,231 @@ func TestReloadConfig(t *testing.T) {
 	}
 }
 
+func TestAddRemoveJob(t *testing.T) {
+	app := EmptyApp()
+	app.Discovery = &mocks.NoopDiscoveryBackend{}
+	app.Bus = events.NewEventBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	completedCh := make(chan struct{}, 10)
+	app.runTasks(ctx, completedCh)
+
+	err := app.AddJob(map[string]interface{}{"name": "debug", "exec": "sleep 10"})
+	if err != nil {
+		t.Fatalf("unexpected error adding job: %v", err)
+	}
+	assert.True(t, app.HasJob("debug"), "expected job to be added")
+	time.Sleep(100 * time.Millisecond)
+	job := app.Jobs[0]
+	assert.Equal(t, "unknown", job.GetStatus().String(), "expected job to be started")
+
+	err = app.AddJob(map[string]interface{}{"name": "debug", "exec": "sleep 10"})
+	assert.Error(t, err, "expected error adding duplicate job")
+	err = app.AddJob(map[string]interface{}{"name": "other", "exec": "sleep 10", "foo": 1})
+	assert.Error(t, err, "expected error adding invalid job")
+	assert.False(t, app.HasJob("other"), "invalid job should not be added")
+	err = app.AddJob(map[string]interface{}{"name": "other", "exec": "sleep 10",
+		"when": map[string]interface{}{"source": "nonexistent", "once": "healthy"}})
+	if assert.Error(t, err, "expected error adding job with unknown source") {
+		assert.Equal(t, "job[other].when.source 'nonexistent' does not match any job or watch", err.Error())
+	}
+	err = app.AddJob(map[string]interface{}{"name": "other", "exec": "sleep 10",
+		"when": map[string]interface{}{"source": "debug", "once": "stopping"}})
+	if assert.Error(t, err, "expected error adding job on a running job's stopping event") {
+		assert.Equal(t, "job[other].when 'stopping' can't be used for a job added to running jobs", err.Error())
+	}
+	err = app.AddJob(map[string]interface{}{"name": "other", "exec": "sleep 10",
+		"when": map[string]interface{}{"source": "debug", "once": "healthy"}})
+	assert.NoError(t, err, "expected job waiting on a running job to be added")
+	assert.True(t, app.RemoveJob("other"), "expected job to be removed")
+	<-completedCh
+
+	assert.False(t, app.RemoveJob("other"), "expected no such job")
+	assert.True(t, app.RemoveJob("debug"), "expected job to be removed")
+	assert.False(t, app.HasJob("debug"), "expected job to be removed")
+	select {
+	case <-completedCh:
+	case <-time.After(time.Second):
+		t.Fatalf("expected removed job to stop")
+	}
+}
+
+// A job that runs on another job's stopping event ignores the global
+// shutdown, but it still stops when it's removed, and then the other
+// job doesn't wait for it when it stops
+func TestRemoveStoppingJob(t *testing.T) {
+	f := testCfgToTempFile(t, `{consul: "consul:8500",
+	jobs: [{name: "other", exec: "sleep 10"},
+		{name: "hook", exec: "true", when: {source: "other", once: "stopping"}}]}`)
+	defer os.Remove(f.Name())
+	app, err := NewApp(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error creating app: %v", err)
+	}
+	app.Bus = events.NewEventBus()
+	app.StopTimeout = 5
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.runTasks(ctx, make(chan struct{}, 10))
+
+	job := app.Jobs[1]
+	assert.True(t, app.RemoveJob("hook"), "expected job to be removed")
+	assert.True(t, job.IsComplete, "expected removed job to have stopped")
+
+	app.Bus.Publish(events.GlobalShutdown)
+	stopped := make(chan struct{})
+	go func() {
+		app.Bus.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected other job to stop without waiting for the removed job")
+	}
+}
+
+func TestReloadChangedJobs(t *testing.T) {
+	f := testCfgToTempFile(t, `{consul: "consul:8500",
+	jobs: [{name: "a", exec: "sleep 10"}, {name: "b", exec: "sleep 10"}],
+	watches: [{name: "upstream", interval: 100}]}`)
+	defer os.Remove(f.Name())
+	app, err := NewApp(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error creating app: %v", err)
+	}
+	app.Bus = events.NewEventBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.runTasks(ctx, make(chan struct{}, 10))
+	jobA, jobB := app.Jobs[0], app.Jobs[1]
+
+	writeCfg := func(text string) {
+		if err := ioutil.WriteFile(f.Name(), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCfg(`{consul: "consul:8500",
+	jobs: [{name: "a", exec: "sleep 10"}, {name: "b", exec: "sleep 11"},
+		{name: "c", exec: "sleep 10"}],
+	watches: [{name: "downstream", interval: 100}]}`)
+	diff, restart, err := app.Reload()
+	assert.NoError(t, err)
+	assert.False(t, restart, "expected only changed jobs to be reloaded")
+	assert.Equal(t, &config.Diff{
+		Jobs:    config.Changes{Added: []string{"c"}, Changed: []string{"b"}},
+		Watches: config.Changes{Added: []string{"downstream"}, Removed: []string{"upstream"}},
+	}, diff)
+	if assert.Len(t, app.Jobs, 3) {
+		assert.True(t, jobA == app.Jobs[0], "expected unchanged job to keep running")
+		assert.Equal(t, "b", app.Jobs[1].Name)
+		assert.False(t, jobB == app.Jobs[1], "expected changed job to be replaced")
+		assert.Equal(t, "c", app.Jobs[2].Name)
+	}
+	if assert.Len(t, app.Watches, 1) {
+		assert.Equal(t, "watch.downstream", app.Watches[0].Name)
+	}
+
+	writeCfg(`{consul: "consul:8501",
+	jobs: [{name: "a", exec: "sleep 10"}]}`)
+	diff, restart, err = app.Reload()
+	assert.NoError(t, err)
+	assert.True(t, restart, "expected consul change to reload everything")
+	assert.True(t, diff.(*config.Diff).Restart)
+	assert.Len(t, app.Jobs, 3, "expected jobs to be left for the full reload")
+
+	writeCfg(`invalid`)
+	_, _, err = app.Reload()
+	assert.Error(t, err, "expected invalid config to return error")
+}
+
+// Reload waits for a changed job to stop without blocking the rest of
+// the App, and doesn't start its replacement until it has stopped
+func TestReloadWaitsForStoppingJob(t *testing.T) {
+	// job "a" doesn't stop until the job that runs on its stopping
+	// event has exited
+	hook := `{name: "hook", exec: "sleep 1", when: {source: "a", once: "stopping"}}`
+	f := testCfgToTempFile(t, `{consul: "consul:8500",
+	jobs: [{name: "a", exec: "sleep 10"}, `+hook+`]}`)
+	defer os.Remove(f.Name())
+	app, err := NewApp(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error creating app: %v", err)
+	}
+	app.Bus = events.NewEventBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.runTasks(ctx, make(chan struct{}, 10))
+	oldJob := app.Jobs[0]
+
+	if err := ioutil.WriteFile(f.Name(), []byte(`{consul: "consul:8500",
+	jobs: [{name: "a", exec: "sleep 11"}, `+hook+`]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan struct{})
+	go func() {
+		app.Reload()
+		close(reloaded)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	hasJob := make(chan bool)
+	go func() { hasJob <- app.HasJob("a") }()
+	select {
+	case ok := <-hasJob:
+		assert.True(t, ok)
+	case <-reloaded:
+		t.Fatalf("expected reload to wait for the job to stop")
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("expected the App not to be locked while the job stops")
+	}
+	<-reloaded
+	assert.True(t, oldJob.IsComplete, "expected old job to stop before its replacement")
+	assert.False(t, oldJob == app.Jobs[0], "expected job to be replaced")
+}
+
+// A job whose stopping event starts a job that's removed on reload is
+// restarted, so that it doesn't wait for the removed job when it stops
+func TestReloadRemovedStoppingDependent(t *testing.T) {
+	f := testCfgToTempFile(t, `{consul: "consul:8500",
+	jobs: [{name: "app", exec: "sleep 10"},
+		{name: "preStop", exec: "true", when: {source: "app", once: "stopping"}}]}`)
+	defer os.Remove(f.Name())
+	app, err := NewApp(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error creating app: %v", err)
+	}
+	app.Bus = events.NewEventBus()
+	app.StopTimeout = 5
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.runTasks(ctx, make(chan struct{}, 10))
+	oldJob := app.Jobs[0]
+
+	if err := ioutil.WriteFile(f.Name(), []byte(`{consul: "consul:8500",
+	jobs: [{name: "app", exec: "sleep 10"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diff, _, err := app.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, config.Changes{Removed: []string{"preStop"}, Changed: []string{"app"}},
+		diff.(*config.Diff).Jobs)
+	if assert.Len(t, app.Jobs, 1) {
+		assert.False(t, oldJob == app.Jobs[0], "expected app to be replaced")
+	}
+
+	app.Bus.Publish(events.GlobalShutdown)
+	stopped := make(chan struct{})
+	go func() {
+		app.Bus.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected app to stop without waiting for the removed job")
+	}
+}
+
 // ----------------------------------------------------
 // test helpers
 
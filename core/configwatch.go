@@ -0,0 +1,73 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/joyent/containerpilot/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// configWatchDebounce is how long the config file has to go without
+// changing before we reload it, so that we don't reload a file that's
+// only partially written, or reload once for each of several writes
+var configWatchDebounce = time.Second
+
+// watchConfigFile reloads the App through its control server whenever
+// the config file changes, if the new config is valid. It stops watching
+// when the context is canceled.
+func (a *App) watchConfigFile(ctx context.Context) {
+	srv := a.ControlServer
+	err := watchConfigChanges(ctx, a.ConfigFlag, configWatchDebounce,
+		func() {
+			if err := srv.Reload(); err != nil {
+				log.Errorf("config file changed but could not be reloaded: %v", err)
+			}
+		})
+	if err != nil {
+		log.Errorf("unable to watch config file: %v", err)
+	}
+}
+
+// watchConfigChanges calls reload once the config file at configPath has
+// changed and then gone unchanged for the debounce period, but only if
+// the contents of the file are different and the new config is valid
+func watchConfigChanges(ctx context.Context, configPath string,
+	debounce time.Duration, reload func()) error {
+
+	changes, err := watchFile(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	last, _ := ioutil.ReadFile(configPath)
+	go func() {
+		var settled <-chan time.Time
+		for {
+			select {
+			case _, ok := <-changes:
+				if !ok {
+					return
+				}
+				settled = time.After(debounce)
+			case <-settled:
+				settled = nil
+				data, err := ioutil.ReadFile(configPath)
+				if err != nil || bytes.Equal(data, last) {
+					continue
+				}
+				last = data
+				if _, err := config.LoadConfig(configPath); err != nil {
+					log.Errorf("config file changed but is invalid, not reloading: %v", err)
+					continue
+				}
+				log.Infof("config file changed, reloading")
+				reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
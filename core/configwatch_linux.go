@@ -0,0 +1,78 @@
+//go:build linux
+// +build linux
+
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the changes to the directory that might have changed the file. A file
+// that's replaced by renaming another file over it (as most editors and
+// tools that write files atomically do) is moved or created rather than
+// written.
+const watchFileEvents = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_TO |
+	syscall.IN_MOVED_FROM
+
+// watchFile uses inotify to watch the directory containing the file, and
+// sends on the channel whenever the directory changes. Watching the file
+// itself wouldn't catch a file that's replaced rather than written. The
+// channel is closed once the context is canceled.
+func watchFile(ctx context.Context, path string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	wd, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), watchFileEvents)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	lock := &sync.Mutex{}
+	closed := false
+	go func() {
+		<-ctx.Done()
+		lock.Lock()
+		defer lock.Unlock()
+		if !closed {
+			// removing the watch wakes up the blocked read below
+			syscall.InotifyRmWatch(fd, uint32(wd))
+		}
+	}()
+	go func() {
+		defer func() {
+			lock.Lock()
+			defer lock.Unlock()
+			closed = true
+			syscall.Close(fd)
+			close(changes)
+		}()
+		buf := make([]byte, syscall.SizeofInotifyEvent*64+syscall.PathMax)
+		for {
+			n, err := syscall.Read(fd, buf)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil || n < syscall.SizeofInotifyEvent {
+				log.Errorf("stopped watching %s: %v", path, err)
+				return
+			}
+			select {
+			case changes <- struct{}{}:
+			default: // there's already a change waiting
+			}
+		}
+	}()
+	return changes, nil
+}
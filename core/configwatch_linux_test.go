@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchConfigChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "containerpilot-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "containerpilot.json5")
+	writeCfg := func(path, text string) {
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCfg(configPath, `{consul: "consul:8500"}`)
+
+	reloads := make(chan struct{}, 10)
+	expectReload := func(msg string) {
+		select {
+		case <-reloads:
+		case <-time.After(5 * time.Second):
+			t.Fatal(msg)
+		}
+	}
+	// there's no event for a reload that doesn't happen, so we give the
+	// watcher a few debounce periods to do it
+	expectNoReload := func(msg string) {
+		time.Sleep(150 * time.Millisecond)
+		assert.Len(t, reloads, 0, msg)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = watchConfigChanges(ctx, configPath, 50*time.Millisecond,
+		func() { reloads <- struct{}{} })
+	if err != nil {
+		t.Fatalf("unexpected error watching config: %v", err)
+	}
+
+	writeCfg(configPath, `{consul: "consul:8500"}`)
+	expectNoReload("expected no reload for unchanged config")
+
+	writeCfg(configPath, `{consul: "consul:8500", stopTimeout: 1}`)
+	writeCfg(configPath, `{consul: "consul:8500", stopTimeout: 2}`)
+	expectReload("expected reload for changed config")
+	expectNoReload("expected writes to be debounced")
+
+	writeCfg(configPath, `{consul: "consul:8500", stopTimeout: "x"}`)
+	expectNoReload("expected no reload for invalid config")
+
+	replacement := filepath.Join(dir, "containerpilot.json5.tmp")
+	writeCfg(replacement, `{consul: "consul:8500", stopTimeout: 3}`)
+	if err := os.Rename(replacement, configPath); err != nil {
+		t.Fatal(err)
+	}
+	expectReload("expected reload for replaced config")
+
+	writeCfg(filepath.Join(dir, "other.json5"), `{}`)
+	expectNoReload("expected no reload for other files")
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	writeCfg(configPath, `{consul: "consul:8500", stopTimeout: 4}`)
+	expectNoReload("expected no reload after cancel")
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// watchFile needs inotify, so watching the config file is only supported
+// on Linux
+func watchFile(ctx context.Context, path string) (<-chan struct{}, error) {
+	return nil, errors.New("watching the config file is only supported on Linux")
+}
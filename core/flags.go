@@ -47,12 +47,22 @@ func GetArgs() (subcommands.Handler, subcommands.Params) {
 
 	var versionFlag bool
 	var templateFlag bool
+	var schemaFlag bool
 	var reloadFlag bool
 	var pingFlag bool
 
 	var configPath string
 	var renderFlag string
 	var maintFlag string
+	var graphFlag string
+	var simulateFlag string
+	var validateFlag string
+	var startFlag string
+	var stopFlag string
+	var restartFlag string
+	var jobFlag string
+	var reasonFlag string
+	var ttlFlag string
 
 	var putMetricFlags MultiFlag
 	var putEnvFlags MultiFlag
@@ -78,6 +88,26 @@ func GetArgs() (subcommands.Handler, subcommands.Params) {
 			`Toggle maintenance mode for a ContainerPilot process through its control socket.
 	Options: '-maintenance enable' or '-maintenance disable'`)
 
+		flag.StringVar(&jobFlag, "job", "",
+			`Name of a single job to put into or take out of maintenance mode when
+	'-maintenance' is used. Defaults to all jobs.`)
+
+		flag.StringVar(&reasonFlag, "reason", "",
+			"Reason for entering maintenance mode, reported to Consul, when '-maintenance enable' is used.")
+
+		flag.StringVar(&ttlFlag, "ttl", "",
+			`Time after which maintenance mode exits automatically when '-maintenance enable'
+	is used. Defaults to never.`)
+
+		flag.StringVar(&startFlag, "start", "",
+			"Start a job of a ContainerPilot process through its control socket.")
+
+		flag.StringVar(&stopFlag, "stop", "",
+			"Stop a job of a ContainerPilot process through its control socket.")
+
+		flag.StringVar(&restartFlag, "restart", "",
+			"Restart a job of a ContainerPilot process through its control socket.")
+
 		flag.Var(&putMetricFlags, "putmetric",
 			`Update metrics of a ContainerPilot process through its control socket.
 	Pass metrics in the format: 'key=value'`)
@@ -89,6 +119,21 @@ func GetArgs() (subcommands.Handler, subcommands.Params) {
 		flag.BoolVar(&pingFlag, "ping", false,
 			"Check that the ContainerPilot control socket is up.")
 
+		flag.StringVar(&graphFlag, "graph", "",
+			`Print the graph of how jobs, watches, and signals trigger each other and quit.
+	Options: '-graph dot' (Graphviz) or '-graph json'`)
+
+		flag.BoolVar(&schemaFlag, "schema", false,
+			"Print the JSON Schema of the configuration file and quit.")
+
+		flag.StringVar(&validateFlag, "validate", "",
+			`Validate the configuration, print every error found, and quit. Exits non-zero
+	if the configuration is invalid. Options: '-validate text' or '-validate json'`)
+
+		flag.StringVar(&simulateFlag, "simulate", "",
+			`Simulate the configuration against a JSON5 scenario file on a virtual clock,
+	print the timeline of events, and quit.`)
+
 		flag.Parse()
 	}
 
@@ -98,6 +143,9 @@ func GetArgs() (subcommands.Handler, subcommands.Params) {
 			GitHash: version.GitHash,
 		}
 	}
+	if schemaFlag {
+		return subcommands.SchemaHandler, subcommands.Params{}
+	}
 	if configPath == "" {
 		configPath = os.Getenv("CONTAINERPILOT")
 	}
@@ -107,6 +155,24 @@ func GetArgs() (subcommands.Handler, subcommands.Params) {
 			RenderFlag: renderFlag,
 		}
 	}
+	if graphFlag != "" {
+		return subcommands.GraphHandler, subcommands.Params{
+			ConfigPath: configPath,
+			GraphFlag:  graphFlag,
+		}
+	}
+	if validateFlag != "" {
+		return subcommands.ValidateHandler, subcommands.Params{
+			ConfigPath:   configPath,
+			ValidateFlag: validateFlag,
+		}
+	}
+	if simulateFlag != "" {
+		return SimulateHandler, subcommands.Params{
+			ConfigPath:   configPath,
+			SimulateFlag: simulateFlag,
+		}
+	}
 	if reloadFlag {
 		return subcommands.ReloadHandler, subcommands.Params{
 			ConfigPath: configPath,
@@ -116,6 +182,30 @@ func GetArgs() (subcommands.Handler, subcommands.Params) {
 		return subcommands.MaintenanceHandler, subcommands.Params{
 			ConfigPath:      configPath,
 			MaintenanceFlag: maintFlag,
+			JobName:         jobFlag,
+			Reason:          reasonFlag,
+			TTL:             ttlFlag,
+		}
+	}
+	if startFlag != "" {
+		return subcommands.JobHandler, subcommands.Params{
+			ConfigPath: configPath,
+			JobName:    startFlag,
+			JobAction:  "start",
+		}
+	}
+	if stopFlag != "" {
+		return subcommands.JobHandler, subcommands.Params{
+			ConfigPath: configPath,
+			JobName:    stopFlag,
+			JobAction:  "stop",
+		}
+	}
+	if restartFlag != "" {
+		return subcommands.JobHandler, subcommands.Params{
+			ConfigPath: configPath,
+			JobName:    restartFlag,
+			JobAction:  "restart",
 		}
 	}
 	if putEnvFlags.Len() != 0 {
@@ -0,0 +1,203 @@
+package core
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joyent/containerpilot/config"
+	"github.com/joyent/containerpilot/events"
+	"github.com/joyent/containerpilot/jobs"
+	"github.com/joyent/containerpilot/watches"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reload loads the configuration file again and applies the changes to
+// the running jobs and watches. Jobs and watches that haven't changed
+// keep running; only those that were added, removed, or changed are
+// stopped or started. If anything else in the configuration changed,
+// Reload changes nothing and returns true so that the caller reloads
+// everything instead. Either way the differences are logged and returned.
+func (a *App) Reload() (interface{}, bool, error) {
+	cfg, err := config.LoadConfig(a.ConfigFlag)
+	if err != nil {
+		log.Errorf("error initializing config: %v", err)
+		return nil, false, err
+	}
+	a.changeLock.Lock()
+	defer a.changeLock.Unlock()
+	a.jobsLock.RLock()
+	if a.cfg == nil || a.runCtx == nil {
+		a.jobsLock.RUnlock()
+		return nil, true, nil
+	}
+	diff := a.cfg.Diff(cfg)
+	a.diffAddedJobs(cfg, diff)
+	stopping := a.jobsNamed(append(append([]string{}, diff.Jobs.Removed...), diff.Jobs.Changed...))
+	a.jobsLock.RUnlock()
+	if diff.Restart {
+		log.Infof("reloading everything: %s", diff)
+		return diff, true, nil
+	}
+	log.Infof("reloading: %s", diff)
+
+	// the jobs being replaced can take up to the stop timeout to stop, so
+	// we don't hold the jobsLock while we wait for them
+	a.stopJobs(stopping)
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	a.reloadJobs(cfg, diff)
+	a.reloadWatches(cfg, diff)
+	a.cfg = cfg
+	return diff, false, nil
+}
+
+// diffAddedJobs updates the diff for jobs that were added through the
+// control plane, which aren't in the configuration file. These are
+// removed on reload, unless the file now has a job with the same name,
+// in which case they're changed.
+func (a *App) diffAddedJobs(cfg *config.Config, diff *config.Diff) {
+	for _, job := range a.Jobs {
+		if hasJobConfig(a.cfg, job.Name) {
+			continue
+		}
+		if !hasJobConfig(cfg, job.Name) {
+			diff.Jobs.Removed = append(diff.Jobs.Removed, job.Name)
+			continue
+		}
+		added := diff.Jobs.Added[:0]
+		for _, name := range diff.Jobs.Added {
+			if name != job.Name {
+				added = append(added, name)
+			}
+		}
+		diff.Jobs.Added = added
+		diff.Jobs.Changed = append(diff.Jobs.Changed, job.Name)
+	}
+	sort.Strings(diff.Jobs.Removed)
+	sort.Strings(diff.Jobs.Changed)
+}
+
+func hasJobConfig(cfg *config.Config, name string) bool {
+	for _, jobCfg := range cfg.Jobs {
+		if jobCfg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jobsNamed returns the App's jobs with any of the names. The caller must
+// hold the jobsLock.
+func (a *App) jobsNamed(names []string) []*jobs.Job {
+	named := []*jobs.Job{}
+	for _, job := range a.Jobs {
+		for _, name := range names {
+			if job.Name == name {
+				named = append(named, job)
+			}
+		}
+	}
+	return named
+}
+
+// reloadJobs replaces the jobs that were removed or changed, which must
+// have been stopped already, with the jobs that were added or changed.
+// The caller must hold the jobsLock.
+func (a *App) reloadJobs(cfg *config.Config, diff *config.Diff) {
+	starting := map[string]bool{}
+	for _, name := range append(append([]string{}, diff.Jobs.Added...), diff.Jobs.Changed...) {
+		starting[name] = true
+	}
+	running := map[string]*jobs.Job{}
+	for _, job := range a.Jobs {
+		running[job.Name] = job
+	}
+	var (
+		jobCfgs []*jobs.Config
+		kept    []*jobs.Job
+	)
+	for _, jobCfg := range cfg.Jobs {
+		if starting[jobCfg.Name] {
+			jobCfgs = append(jobCfgs, jobCfg)
+		} else if job, ok := running[jobCfg.Name]; ok {
+			kept = append(kept, job)
+		}
+	}
+	a.Jobs = append(kept, a.startJobs(jobCfgs)...)
+}
+
+// stopJobs stops the jobs and waits for each to finish, so that a
+// replacement job doesn't subscribe to events or register its service
+// before the old one has unsubscribed and deregistered. Any job still
+// running after the App's stop timeout has its processes killed, and
+// then we keep waiting for it to finish. The caller must not hold the
+// jobsLock, because the jobs' completion needs it.
+func (a *App) stopJobs(stopping []*jobs.Job) {
+	waiting := map[string]*jobs.Job{}
+	for _, job := range stopping {
+		a.Telemetry.UnmonitorJob(job.Name)
+		if !job.IsComplete {
+			waiting[job.Name] = job
+		}
+	}
+	if len(waiting) == 0 {
+		return
+	}
+	stopped := &events.Subscriber{Rx: make(chan events.Event, 1000)}
+	stopped.Subscribe(a.Bus)
+	defer stopped.Unsubscribe()
+	for name := range waiting {
+		a.Bus.Publish(events.Event{Code: events.Remove, Source: name})
+	}
+	timeout := time.After(time.Duration(a.StopTimeout) * time.Second)
+	for len(waiting) > 0 {
+		select {
+		case event := <-stopped.Rx:
+			if event.Code == events.Stopped {
+				delete(waiting, event.Source)
+			}
+			stopped.Handled()
+		case <-timeout:
+			for _, job := range waiting {
+				log.Infof("killing processes for job %#v", job.Name)
+				job.Kill()
+			}
+			timeout = nil
+		}
+	}
+}
+
+// reloadWatches stops the watches that were removed or changed and starts
+// the watches that were added or changed
+func (a *App) reloadWatches(cfg *config.Config, diff *config.Diff) {
+	stopping := map[string]bool{}
+	for _, name := range append(append([]string{}, diff.Watches.Removed...), diff.Watches.Changed...) {
+		stopping["watch."+name] = true
+	}
+	starting := map[string]bool{}
+	for _, name := range append(append([]string{}, diff.Watches.Added...), diff.Watches.Changed...) {
+		starting["watch."+name] = true
+	}
+	running := map[string]*watches.Watch{}
+	for _, watch := range a.Watches {
+		if stopping[watch.Name] {
+			watch.Receive(events.Event{Code: events.Quit, Source: watch.Name})
+			a.Telemetry.UnmonitorWatch(watch.Name)
+		} else {
+			running[watch.Name] = watch
+		}
+	}
+	var newWatches, started []*watches.Watch
+	for _, watchCfg := range cfg.Watches {
+		if starting[watchCfg.Name] {
+			watch := watches.NewSimulatedWatch(watchCfg, a.clock)
+			watch.Run(a.runCtx, a.Bus)
+			started = append(started, watch)
+			newWatches = append(newWatches, watch)
+		} else if watch, ok := running[watchCfg.Name]; ok {
+			newWatches = append(newWatches, watch)
+		}
+	}
+	a.Telemetry.MonitorWatches(started)
+	a.Watches = newWatches
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/joyent/containerpilot/commands"
+	"github.com/joyent/containerpilot/config"
+	"github.com/joyent/containerpilot/discovery"
+	"github.com/joyent/containerpilot/events"
+	"github.com/joyent/containerpilot/simulate"
+	"github.com/joyent/containerpilot/subcommands"
+	log "github.com/sirupsen/logrus"
+)
+
+// SimulateHandler runs the configuration against the scenario file on a
+// virtual clock and prints the timeline of events. The App's jobs run
+// the stubbed results from the scenario rather than their real execs.
+func SimulateHandler(params subcommands.Params) error {
+	// the timeline is the output we care about, not the jobs' logging
+	log.SetLevel(log.WarnLevel)
+	return simulateConfig(params.ConfigPath, params.SimulateFlag, os.Stdout)
+}
+
+func simulateConfig(configPath, scenarioPath string, out io.Writer) error {
+	scenario, err := simulate.LoadScenario(scenarioPath)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	clock := events.NewVirtualClock(start)
+	app, err := NewSimulatedApp(configPath, simulate.NewBackend(scenario, clock),
+		clock, scenario.Stub)
+	if err != nil {
+		return err
+	}
+	app.Simulate(clock, start.Add(scenario.Duration),
+		func(at time.Time, event events.Event) {
+			if event.Code == events.TimerExpired {
+				return // internal timers are noise in the timeline
+			}
+			fmt.Fprintf(out, "%10s  %-24s %s\n", at.Sub(start), event.Source, event.Code)
+		})
+	return nil
+}
+
+// NewSimulatedApp creates an App from the config for a simulation. The
+// App uses the discovery backend in place of Consul and has no control
+// server or telemetry endpoint. Its jobs and watches run on the clock,
+// and its jobs run the stubs in place of their execs and health checks.
+func NewSimulatedApp(configFlag string, disc discovery.Backend,
+	clock events.Clock, stubs commands.Stubs) (*App, error) {
+	cfg, err := config.LoadConfigWithDiscovery(configFlag, disc)
+	if err != nil {
+		return nil, err
+	}
+	a := EmptyApp()
+	a.clock = clock
+	a.stubs = stubs
+	a.StopTimeout = cfg.StopTimeout
+	a.Discovery = cfg.Discovery
+	a.Jobs = a.newJobs(cfg.Jobs)
+	a.Watches = a.newWatches(cfg.Watches)
+	a.ConfigFlag = configFlag
+	return a, nil
+}
+
+// Simulate runs the App's jobs and watches on the virtual clock, which
+// must be the clock the App was created with. Whenever the App has
+// finished reacting to the last event, the clock is advanced to the next
+// timer.
+// The simulation shuts down once all the jobs are complete or the clock
+// reaches the end, and each event on the EventBus is passed to record
+// along with the virtual time it was published.
+func (a *App) Simulate(clock *events.VirtualClock, end time.Time,
+	record func(time.Time, events.Event)) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a.Bus = events.NewEventBus()
+	recorder := &events.Subscriber{Rx: make(chan events.Event, 1000)}
+	recorder.Subscribe(a.Bus)
+	recorderDone := make(chan struct{})
+	go func() {
+		defer close(recorderDone)
+		for event := range recorder.Rx {
+			record(clock.Now(), event)
+			recorder.Handled()
+		}
+	}()
+
+	completedCh := make(chan struct{}, len(a.Jobs))
+	go func() {
+		for {
+			select {
+			case <-completedCh:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	a.runTasks(ctx, completedCh)
+
+	// the App is waiting on the clock once it has settled: every event
+	// on the EventBus has been handled and no timer is due
+	run := func(until time.Time) {
+		for {
+			clock.Settle(a.Bus)
+			if a.jobsComplete() {
+				return
+			}
+			next, ok := clock.Next()
+			if !ok || next.After(until) {
+				clock.AdvanceTo(until)
+				clock.Settle(a.Bus)
+				return
+			}
+			clock.AdvanceTo(next)
+		}
+	}
+
+	run(end)
+	if !a.jobsComplete() {
+		a.Bus.Shutdown()
+		// give the jobs their stop timeouts to shut down gracefully
+		run(clock.Now().Add(time.Duration(a.StopTimeout) * time.Second))
+	}
+	// anything still running after its stop timeout is abandoned, just as
+	// App.Run kills it, so we don't wait on the EventBus here
+	cancel()
+	clock.Settle(a.Bus)
+	recorder.Unsubscribe()
+	close(recorder.Rx)
+	<-recorderDone
+}
+
+func (a *App) jobsComplete() bool {
+	for _, job := range a.Jobs {
+		if !job.IsComplete {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	cfg := testCfgToTempFile(t, `{
+	consul: "consul:8500",
+	jobs: [
+		{name: "preStart", exec: "preStart"},
+		{name: "app", exec: "app", restarts: 1,
+		 when: {source: "preStart", once: "exitSuccess"}},
+		{name: "db-ready", exec: "db-ready",
+		 when: {source: "watch.db", once: "healthy"}}
+	],
+	watches: [{name: "db", interval: 5}]}`)
+	defer os.Remove(cfg.Name())
+	scenario := testCfgToTempFile(t, `{
+	duration: "2m",
+	execs: {
+		preStart: [{duration: "3s"}],
+		app: [{exitCode: 1, duration: "10s"}, {duration: "forever"}]
+	},
+	services: {db: [{at: "30s", healthy: true}]}}`)
+	defer os.Remove(scenario.Name())
+
+	var out bytes.Buffer
+	if err := simulateConfig(cfg.Name(), scenario.Name(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	expected := []string{
+		"0s global Startup",
+		"3s preStart ExitSuccess",
+		"3s preStart Stopping",
+		"3s preStart Stopped",
+		"13s app ExitFailed",
+		"13s app: exit status 1 Error",
+		"30s watch.db StatusChanged",
+		"30s watch.db StatusHealthy",
+		"30s db-ready ExitSuccess",
+		"30s db-ready Stopping",
+		"30s db-ready Stopped",
+		"2m0s global Shutdown",
+		"2m0s app Stopping",
+		"2m0s app Stopped",
+	}
+	assert.Equal(t, expected, lines[:len(expected)])
+}
+
+func TestSimulateBadScenario(t *testing.T) {
+	err := simulateConfig("", "./testdata/missing.json5", &bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -8,14 +8,17 @@ import (
 	"github.com/joyent/containerpilot/config/decode"
 )
 
-type parsedConfig struct {
+// ConsulConfig is the configuration of the Consul backend, when it's
+// given as an object rather than an address
+type ConsulConfig struct {
 	Address string          `mapstructure:"address"`
 	Scheme  string          `mapstructure:"scheme"`
 	Token   string          `mapstructure:"token"`
-	TLS     parsedTLSConfig `mapstructure:"tls"` // optional TLS settings
+	TLS     ConsulTLSConfig `mapstructure:"tls"` // optional TLS settings
 }
 
-type parsedTLSConfig struct {
+// ConsulTLSConfig is the TLS configuration of the Consul backend
+type ConsulTLSConfig struct {
 	HTTPCAFile        string `mapstructure:"cafile"`
 	HTTPCAPath        string `mapstructure:"capath"`
 	HTTPClientCert    string `mapstructure:"clientcert"`
@@ -24,9 +27,9 @@ type parsedTLSConfig struct {
 	HTTPSSLVerify     bool   `mapstructure:"verify"`
 }
 
-// override an already-parsed parsedConfig with any options that might
+// override an already-parsed ConsulConfig with any options that might
 // be set in the environment and then return the TLSConfig
-func getTLSConfig(parsed *parsedConfig) api.TLSConfig {
+func getTLSConfig(parsed *ConsulConfig) api.TLSConfig {
 	if cafile := os.Getenv("CONSUL_CACERT"); cafile != "" {
 		parsed.TLS.HTTPCAFile = cafile
 	}
@@ -61,7 +64,7 @@ func getTLSConfig(parsed *parsedConfig) api.TLSConfig {
 }
 
 func configFromMap(raw map[string]interface{}) (*api.Config, error) {
-	parsed := &parsedConfig{}
+	parsed := &ConsulConfig{}
 	if err := decode.ToStruct(raw, parsed); err != nil {
 		return nil, err
 	}
@@ -76,7 +79,7 @@ func configFromMap(raw map[string]interface{}) (*api.Config, error) {
 
 func configFromURI(uri string) (*api.Config, error) {
 	address, scheme := parseRawURI(uri)
-	parsed := &parsedConfig{Address: address, Scheme: scheme}
+	parsed := &ConsulConfig{Address: address, Scheme: scheme}
 	config := &api.Config{
 		Address:   parsed.Address,
 		Scheme:    parsed.Scheme,
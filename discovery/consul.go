@@ -81,6 +81,18 @@ func (c *Consul) ServiceDeregister(serviceID string) error {
 	return c.Agent().ServiceDeregister(serviceID)
 }
 
+// EnableServiceMaintenance wraps the Consul.Agent's EnableServiceMaintenance
+// method, and is used to put a service into maintenance mode with a reason
+func (c *Consul) EnableServiceMaintenance(serviceID, reason string) error {
+	return c.Agent().EnableServiceMaintenance(serviceID, reason)
+}
+
+// DisableServiceMaintenance wraps the Consul.Agent's DisableServiceMaintenance
+// method, and is used to take a service out of maintenance mode
+func (c *Consul) DisableServiceMaintenance(serviceID string) error {
+	return c.Agent().DisableServiceMaintenance(serviceID)
+}
+
 // CheckForUpstreamChanges requests the set of healthy instances of a
 // service from Consul and checks whether there has been a change since
 // the last check.
@@ -80,6 +80,7 @@ func TestWithConsul(t *testing.T) {
 	testServer.WaitForAPI()
 
 	t.Run("TestConsulTTLPass", testConsulTTLPass(testServer))
+	t.Run("TestConsulTTLWarningAndFailure", testConsulTTLWarningAndFailure(testServer))
 	t.Run("TestConsulRegisterWithInitialStatus", testConsulRegisterWithInitialStatus(testServer))
 	t.Run("TestConsulReregister", testConsulReregister(testServer))
 	t.Run("TestConsulCheckForChanges", testConsulCheckForChanges(testServer))
@@ -102,6 +103,35 @@ func testConsulTTLPass(testServer *TestServer) func(*testing.T) {
 	}
 }
 
+func testConsulTTLWarningAndFailure(testServer *TestServer) func(*testing.T) {
+	return func(t *testing.T) {
+		consul, _ := NewConsul(testServer.HTTPAddr)
+		name := fmt.Sprintf("TestConsulTTLWarningAndFailure")
+		service := generateServiceDefinition(name, consul)
+		checkID := fmt.Sprintf("service:%s", service.ID)
+
+		service.SendWarning("degraded") // force registration and 1st heartbeat
+		checks, _ := consul.Agent().Checks()
+		check := checks[checkID]
+		if check.Status != "warning" {
+			t.Fatalf("status of check %s should be 'warning' but is %s", checkID, check.Status)
+		}
+		if check.Output != "degraded" {
+			t.Fatalf("output of check %s should be 'degraded' but is %s", checkID, check.Output)
+		}
+
+		service.SendFailure("connection refused")
+		checks, _ = consul.Agent().Checks()
+		check = checks[checkID]
+		if check.Status != "critical" {
+			t.Fatalf("status of check %s should be 'critical' but is %s", checkID, check.Status)
+		}
+		if check.Output != "connection refused" {
+			t.Fatalf("output of check %s should be 'connection refused' but is %s", checkID, check.Output)
+		}
+	}
+}
+
 func testConsulRegisterWithInitialStatus(testServer *TestServer) func(*testing.T) {
 	return func(t *testing.T) {
 		consul, _ := NewConsul(testServer.HTTPAddr)
@@ -11,4 +11,6 @@ type Backend interface {
 	UpdateTTL(checkID, output, status string) error
 	ServiceDeregister(serviceID string) error
 	ServiceRegister(service *api.AgentServiceRegistration) error
+	EnableServiceMaintenance(serviceID, reason string) error
+	DisableServiceMaintenance(serviceID string) error
 }
@@ -32,18 +32,59 @@ func (service *ServiceDefinition) Deregister() {
 	}
 }
 
-// MarkForMaintenance removes the service from Consul.
-func (service *ServiceDefinition) MarkForMaintenance() {
-	service.Deregister()
+// MarkForMaintenance puts the service into Consul's maintenance mode with
+// the given reason, so that it's no longer discoverable but Consul still
+// knows about it.
+func (service *ServiceDefinition) MarkForMaintenance(reason string) {
+	log.Debugf("entering maintenance: %s", service.ID)
+	if err := service.Consul.EnableServiceMaintenance(service.ID, reason); err != nil {
+		log.Infof("entering maintenance failed: %s", err)
+	}
+}
+
+// ClearMaintenance takes the service out of Consul's maintenance mode.
+func (service *ServiceDefinition) ClearMaintenance() {
+	log.Debugf("exiting maintenance: %s", service.ID)
+	if err := service.Consul.DisableServiceMaintenance(service.ID); err != nil {
+		log.Infof("exiting maintenance failed: %s", err)
+	}
 }
 
 // SendHeartbeat writes a TTL check status=ok to the Consul store.
 func (service *ServiceDefinition) SendHeartbeat() error {
+	return service.SendPassing("ok")
+}
+
+// SendPassing writes a TTL check status=passing with the given output
+// to the Consul store.
+func (service *ServiceDefinition) SendPassing(output string) error {
+	return service.updateTTL(api.HealthPassing, output, "pass")
+}
+
+// SendWarning writes a TTL check status=warning with the given output to
+// the Consul store. The service stays discoverable but is reported as
+// degraded.
+func (service *ServiceDefinition) SendWarning(output string) error {
+	return service.updateTTL(api.HealthWarning, output, "warn")
+}
+
+// SendFailure writes a TTL check status=critical with the given output to
+// the Consul store, so that the reason for the failure is visible without
+// waiting for the TTL to expire. A service that has never been registered
+// isn't registered just to mark it as failed.
+func (service *ServiceDefinition) SendFailure(output string) error {
+	if !service.wasRegistered {
+		return nil
+	}
+	return service.updateTTL(api.HealthCritical, output, "fail")
+}
+
+func (service *ServiceDefinition) updateTTL(registerStatus, output, status string) error {
 	// Make sure the service is registered.
-	service.register(api.HealthPassing)
+	service.register(registerStatus)
 
 	checkID := fmt.Sprintf("service:%s", service.ID)
-	if err := service.Consul.UpdateTTL(checkID, "ok", "pass"); err != nil {
+	if err := service.Consul.UpdateTTL(checkID, output, status); err != nil {
 		log.Warnf("service update TTL failed: %s", err)
 	}
 
@@ -15,6 +15,13 @@ type EventBus struct {
 	reload   bool
 	done     sync.WaitGroup
 
+	// count of events received by Subscribers that they haven't yet
+	// finished handling, so that a simulation can tell when the App is
+	// idle. This has its own lock because it's updated while Publish
+	// holds the bus lock.
+	pending     int
+	pendingCond *sync.Cond
+
 	// circular buffer of events
 	head int
 	tail int
@@ -33,6 +40,8 @@ func (bus *EventBus) enqueue(event Event) {
 // DebugEvents ...
 func (bus *EventBus) DebugEvents() []Event {
 	time.Sleep(100 * time.Millisecond)
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
 	events := []Event{}
 	for {
 		if bus.head == -1 {
@@ -84,6 +93,8 @@ func NewEventBus() *EventBus {
 		head:     -1,
 		tail:     0,
 		reload:   false,
+
+		pendingCond: sync.NewCond(&sync.Mutex{}),
 	}
 }
 
@@ -118,6 +129,21 @@ func (bus *EventBus) Unsubscribe(subscriber EventSubscriber) {
 	if _, ok := bus.registry[sub]; ok {
 		delete(bus.registry, sub)
 	}
+	// nothing will handle the events left in the receive channel
+	left := 0
+drain:
+	for {
+		select {
+		case _, ok := <-sub.Rx:
+			if !ok {
+				break drain
+			}
+			left++
+		default:
+			break drain
+		}
+	}
+	bus.received(-left)
 	bus.done.Done()
 }
 
@@ -139,6 +165,27 @@ func (bus *EventBus) Publish(event Event) {
 	bus.enqueue(event)
 }
 
+// received adds to the count of events that Subscribers have received
+// but not finished handling, and wakes up WaitDrained when it reaches 0
+func (bus *EventBus) received(n int) {
+	bus.pendingCond.L.Lock()
+	defer bus.pendingCond.L.Unlock()
+	bus.pending += n
+	if bus.pending <= 0 {
+		bus.pendingCond.Broadcast()
+	}
+}
+
+// WaitDrained blocks until every event received by a Subscriber has been
+// handled by it.
+func (bus *EventBus) WaitDrained() {
+	bus.pendingCond.L.Lock()
+	defer bus.pendingCond.L.Unlock()
+	for bus.pending > 0 {
+		bus.pendingCond.Wait()
+	}
+}
+
 // PublishSignal publishes a signal event through the EventBus to any Jobs that
 // are subscribed to trigger on them.
 func (bus *EventBus) PublishSignal(sig string) {
@@ -0,0 +1,193 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the event timers and for anything else
+// that needs to agree with them about what time it is. It's the WallClock
+// except when ContainerPilot is simulating its configuration.
+type Clock interface {
+	Now() time.Time
+
+	// AfterFunc calls f once the duration has elapsed, or with the
+	// context's error if the context is done first
+	AfterFunc(ctx context.Context, d time.Duration, f func(error))
+
+	// TickFunc calls f every period until the context is done
+	TickFunc(ctx context.Context, period time.Duration, f func())
+}
+
+// WallClock is the Clock that tells the real time
+type WallClock struct{}
+
+// Now returns the current time
+func (WallClock) Now() time.Time { return time.Now() }
+
+// AfterFunc waits for the duration in its own goroutine and then calls f
+func (WallClock) AfterFunc(ctx context.Context, d time.Duration, f func(error)) {
+	go func() {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			f(ctx.Err())
+		case <-timer.C:
+			f(nil)
+		}
+	}()
+}
+
+// TickFunc calls f from its own goroutine on every tick of a time.Ticker
+func (WallClock) TickFunc(ctx context.Context, period time.Duration, f func()) {
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				f()
+			}
+		}
+	}()
+}
+
+// VirtualClock is a Clock whose time only moves when it's advanced, so
+// that hours of timers can be run through in moments. It calls the
+// timers' functions itself as it's advanced, rather than from goroutines
+// of their own, so that once it has been advanced and the EventBus has
+// drained, everything is waiting on the clock again.
+type VirtualClock struct {
+	now     time.Time
+	waiters []*virtualWaiter
+	lock    sync.Mutex
+}
+
+type virtualWaiter struct {
+	ctx      context.Context
+	deadline time.Time
+	period   time.Duration // for TickFunc
+	f        func(error)
+}
+
+// NewVirtualClock creates a VirtualClock that starts at the given time
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the virtual time
+func (c *VirtualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// AfterFunc calls f once the clock has been advanced past the duration,
+// or once the clock is advanced or settled after the context is done
+func (c *VirtualClock) AfterFunc(ctx context.Context, d time.Duration, f func(error)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.waiters = append(c.waiters, &virtualWaiter{
+		ctx: ctx, deadline: c.now.Add(d), f: f})
+}
+
+// TickFunc calls f each time the clock is advanced past another period,
+// until the context is done
+func (c *VirtualClock) TickFunc(ctx context.Context, period time.Duration, f func()) {
+	if period <= 0 {
+		panic("non-positive interval for VirtualClock.TickFunc")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.waiters = append(c.waiters, &virtualWaiter{
+		ctx: ctx, deadline: c.now.Add(period), period: period,
+		f: func(err error) {
+			if err == nil {
+				f()
+			}
+		}})
+}
+
+// Next returns the earliest time at which anything is waiting on the
+// clock, or false if nothing is waiting
+func (c *VirtualClock) Next() (time.Time, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var next time.Time
+	ok := false
+	for _, w := range c.waiters {
+		if w.ctx.Err() != nil {
+			continue
+		}
+		if !ok || w.deadline.Before(next) {
+			next, ok = w.deadline, true
+		}
+	}
+	return next, ok
+}
+
+// AdvanceTo moves the clock forward to the given time, calling the
+// functions of everything waiting on a time up to and including it, in
+// order
+func (c *VirtualClock) AdvanceTo(t time.Time) {
+	for c.fireNext(t) {
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+// Settle waits until everything running on the clock is waiting for it
+// to be advanced again: the EventBus has drained, and the functions of
+// any timers that are due or whose contexts are done have been called.
+func (c *VirtualClock) Settle(bus *EventBus) {
+	for {
+		bus.WaitDrained()
+		if !c.fireNext(c.Now()) {
+			return
+		}
+	}
+}
+
+// fireNext calls the function of one waiter whose context is done, or
+// else of the earliest waiter due by the time t, and returns false if
+// there's no such waiter. A waiter from TickFunc is due again one period
+// later. The function is called without holding the lock, so that it can
+// wait on the clock again.
+func (c *VirtualClock) fireNext(t time.Time) bool {
+	c.lock.Lock()
+	i := -1
+	for j, w := range c.waiters {
+		if w.ctx.Err() != nil {
+			i = j
+			break
+		}
+		if !w.deadline.After(t) && (i == -1 || w.deadline.Before(c.waiters[i].deadline)) {
+			i = j
+		}
+	}
+	if i == -1 {
+		c.lock.Unlock()
+		return false
+	}
+	w := c.waiters[i]
+	err := w.ctx.Err()
+	if err == nil && w.period > 0 {
+		c.waiters[i] = &virtualWaiter{ctx: w.ctx,
+			deadline: w.deadline.Add(w.period), period: w.period, f: w.f}
+	} else {
+		c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+	}
+	if err == nil && w.deadline.After(c.now) {
+		c.now = w.deadline
+	}
+	c.lock.Unlock()
+	w.f(err)
+	return true
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestVirtualClock(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(start)
+	if _, ok := clock.Next(); ok {
+		t.Fatalf("expected nothing waiting on a new clock")
+	}
+	ctx := context.Background()
+	fired := []time.Duration{}
+	record := func(err error) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fired = append(fired, clock.Now().Sub(start))
+	}
+	clock.AfterFunc(ctx, 2*time.Second, record)
+	clock.AfterFunc(ctx, time.Second, record)
+	if next, _ := clock.Next(); next != start.Add(time.Second) {
+		t.Fatalf("expected next at 1s but got %v", next.Sub(start))
+	}
+
+	clock.AdvanceTo(start.Add(time.Second))
+	if len(fired) != 1 || fired[0] != time.Second {
+		t.Fatalf("expected only the 1s waiter to fire but got %v", fired)
+	}
+
+	clock.AdvanceTo(start.Add(time.Minute))
+	if len(fired) != 2 || fired[1] != 2*time.Second {
+		t.Fatalf("expected the 2s waiter to fire at 2s but got %v", fired)
+	}
+	if clock.Now() != start.Add(time.Minute) {
+		t.Fatalf("expected clock at 1m but got %v", clock.Now().Sub(start))
+	}
+	if _, ok := clock.Next(); ok {
+		t.Fatalf("expected nothing waiting after all waiters fired")
+	}
+}
+
+func TestVirtualClockCancel(t *testing.T) {
+	start := time.Now()
+	clock := NewVirtualClock(start)
+	ctx, cancel := context.WithCancel(context.Background())
+	var got error
+	clock.AfterFunc(ctx, time.Hour, func(err error) { got = err })
+	cancel()
+	if _, ok := clock.Next(); ok {
+		t.Fatalf("expected a cancelled waiter not to be next")
+	}
+	clock.Settle(NewEventBus())
+	if got != context.Canceled {
+		t.Fatalf("expected the waiter to get %v but got %v", context.Canceled, got)
+	}
+	if clock.Now() != start {
+		t.Fatalf("expected a cancelled waiter not to move the clock")
+	}
+}
+
+func TestEventTimerVirtualClock(t *testing.T) {
+	start := time.Now()
+	clock := NewVirtualClock(start)
+
+	rx := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	NewEventTimer(ctx, clock, &Subscriber{Rx: rx}, time.Hour, "virtual")
+	expected := Event{Code: TimerExpired, Source: "virtual"}
+	for i := 1; i <= 3; i++ {
+		next, ok := clock.Next()
+		if !ok || next != start.Add(time.Duration(i)*time.Hour) {
+			t.Fatalf("expected tick %d at %vh but got %v", i, i, next.Sub(start))
+		}
+		clock.AdvanceTo(next)
+		select {
+		case event := <-rx:
+			if event != expected {
+				t.Fatalf("expected %v but got %v", expected, event)
+			}
+		default:
+			t.Fatalf("expected tick %d to have been sent", i)
+		}
+	}
+}
+
+// Settle returns only once the bus has drained and every timer that came
+// due while handling its events has fired
+func TestVirtualClockSettle(t *testing.T) {
+	start := time.Now()
+	clock := NewVirtualClock(start)
+	bus := NewEventBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := &Subscriber{Rx: make(chan Event, 10)}
+	sub.Subscribe(bus)
+	handled := make(chan Event, 10)
+	go func() {
+		for event := range sub.Rx {
+			time.Sleep(10 * time.Millisecond) // a slow handler
+			if event.Source == "first" {
+				// a zero timeout comes due without advancing the clock
+				NewEventTimeout(ctx, clock, sub, 0, "second")
+			}
+			handled <- event
+			sub.Handled()
+		}
+	}()
+	NewEventTimeout(ctx, clock, sub, time.Minute, "first")
+	clock.AdvanceTo(start.Add(time.Minute))
+	clock.Settle(bus)
+	if len(handled) != 2 {
+		t.Fatalf("expected both timeouts to be handled but got %d", len(handled))
+	}
+	if _, ok := clock.Next(); ok {
+		t.Fatalf("expected nothing waiting on the settled clock")
+	}
+}
@@ -36,6 +36,12 @@ const (
 	Startup  // fired once after events are set up and event loop is started
 	Shutdown // fired once after all jobs exit or on receiving SIGTERM
 	Signal   // fired when a UNIX signal hits a CP process/supervisor
+	Skipped  // fired when a periodic job skips a run because it's still running
+	Replaced // fired when a periodic job's run is terminated to start the next
+	Start    // sent by the control plane to start the job named by the source
+	Stop     // sent by the control plane to stop the job named by the source
+	Restart  // sent by the control plane to restart the job named by the source
+	Remove   // sent to halt the job named by the source when it's removed
 )
 
 // global events
@@ -48,6 +54,37 @@ var (
 	QuitByTest             = Event{Code: Quit, Source: "closed"}
 )
 
+// configNames are the names of the EventCodes as they're written in the
+// 'when' field of a job's configuration
+var configNames = map[EventCode]string{
+	ExitSuccess:      "exitSuccess",
+	ExitFailed:       "exitFailed",
+	Stopping:         "stopping",
+	Stopped:          "stopped",
+	StatusHealthy:    "healthy",
+	StatusUnhealthy:  "unhealthy",
+	StatusChanged:    "changed",
+	TimerExpired:     "timerExpired",
+	EnterMaintenance: "enterMaintenance",
+	ExitMaintenance:  "exitMaintenance",
+	Error:            "error",
+	Quit:             "quit",
+	Startup:          "startup",
+	Shutdown:         "shutdown",
+	Skipped:          "skipped",
+	Replaced:         "replaced",
+}
+
+// ConfigName returns the name of the EventCode as it's written in the
+// configuration and parsed by FromString, or its String if the code
+// can't be written in the configuration
+func (code EventCode) ConfigName() string {
+	if name, ok := configNames[code]; ok {
+		return name
+	}
+	return code.String()
+}
+
 // FromString parses a string as an EventCode enum
 func FromString(codeName string) (EventCode, error) {
 	switch codeName {
@@ -81,6 +118,10 @@ func FromString(codeName string) (EventCode, error) {
 		return Shutdown, nil
 	case "SIGHUP", "SIGUSR2":
 		return Signal, nil
+	case "skipped":
+		return Skipped, nil
+	case "replaced":
+		return Replaced, nil
 	}
 	return None, fmt.Errorf("%s is not a valid event code", codeName)
 }
@@ -111,3 +111,13 @@ func TestPublishSignal(t *testing.T) {
 		assert.Equal(t, found, expected[n])
 	}
 }
+
+func TestEventCodeConfigName(t *testing.T) {
+	for code := range configNames {
+		parsed, err := FromString(code.ConfigName())
+		assert.NoError(t, err)
+		assert.Equal(t, code, parsed)
+	}
+	assert.Equal(t, "healthy", StatusHealthy.ConfigName())
+	assert.Equal(t, "Signal", Signal.ConfigName())
+}
@@ -8,6 +8,12 @@ type EventSubscriber interface {
 	Receive(Event)
 }
 
+// EventReceiver is implemented by anything that events can be sent to,
+// such as the event timers' targets.
+type EventReceiver interface {
+	Receive(Event)
+}
+
 // Subscriber represents an object which recieves events through the Event bus
 // through its receive channel.
 type Subscriber struct {
@@ -26,11 +32,23 @@ func (sub *Subscriber) Unsubscribe() {
 	sub.Bus.Unsubscribe(sub)
 }
 
-// Receive receives an Event through the receive channel.
+// Receive receives an Event through the receive channel. The EventBus
+// counts the Event as pending until the Subscriber calls Handled.
 func (sub *Subscriber) Receive(event Event) {
+	if sub.Bus != nil {
+		sub.Bus.received(1)
+	}
 	sub.Rx <- event
 }
 
+// Handled tells the EventBus that the Subscriber has finished handling an
+// Event it read from its receive channel.
+func (sub *Subscriber) Handled() {
+	if sub.Bus != nil {
+		sub.Bus.received(-1)
+	}
+}
+
 // Wait waits for the subscriber's EventBus to complete its wait group.
 func (sub *Subscriber) Wait() {
 	sub.Bus.done.Wait()
@@ -2,70 +2,150 @@ package events
 
 import (
 	"context"
+	"math/rand"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-// NewEventTimeout starts a goroutine on a timer that will send a
+// the random source for timer splay and jitter. *rand.Rand isn't safe for
+// concurrent use so it's guarded by a lock.
+var (
+	random     = rand.New(rand.NewSource(time.Now().UnixNano()))
+	randomLock sync.Mutex
+)
+
+// SeedRandom seeds the random source used for timer splay and jitter,
+// so that tests can get repeatable timings.
+func SeedRandom(seed int64) {
+	randomLock.Lock()
+	defer randomLock.Unlock()
+	random = rand.New(rand.NewSource(seed))
+}
+
+// RandomDuration returns a random duration in the range [0, max). It
+// returns 0 if max isn't positive.
+func RandomDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	randomLock.Lock()
+	defer randomLock.Unlock()
+	return time.Duration(random.Int63n(int64(max)))
+}
+
+// send delivers a timer's event to the receiver. Sending potentially
+// races with a closing receive channel, so just recover from the panic.
+func send(rx EventReceiver, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			return
+		}
+	}()
+	rx.Receive(event)
+}
+
+// NewEventTimeout starts a timer on the clock that will send a
 // TimerExpired event when the timer expires
 func NewEventTimeout(
 	ctx context.Context,
-	rx chan Event,
+	clock Clock,
+	rx EventReceiver,
 	tick time.Duration,
 	name string,
 ) {
-	go func() {
-		timeout := time.After(tick)
-		select {
-		case <-ctx.Done():
+	clock.AfterFunc(ctx, tick, func(err error) {
+		if err != nil {
 			return
-		case <-timeout:
-			// sending the timeout event potentially races with a closing
-			// rx channel, so just recover from the panic and exit
-			defer func() {
-				if r := recover(); r != nil {
-					return
-				}
-			}()
-			event := Event{Code: TimerExpired, Source: name}
-			log.Debugf("timeout: %v", event)
-			rx <- event
 		}
-	}()
+		event := Event{Code: TimerExpired, Source: name}
+		log.Debugf("timeout: %v", event)
+		send(rx, event)
+	})
 }
 
-// NewEventTimer starts a goroutine with a timer that will send a
+// NewEventTimer starts a ticker on the clock that will send a
 // TimerExpired event every time the timer expires
 func NewEventTimer(
 	ctx context.Context,
-	rx chan Event,
+	clock Clock,
+	rx EventReceiver,
 	tick time.Duration,
 	name string,
 ) {
-	go func() {
-		ticker := time.NewTicker(tick)
-		// sending the timeout event potentially races with a closing
-		// rx channel, so just recover from the panic and exit
-		defer func() {
-			if r := recover(); r != nil {
-				return
-			}
-		}()
-		for {
-			select {
-			case <-ctx.Done():
+	clock.TickFunc(ctx, tick, func() {
+		event := Event{Code: TimerExpired, Source: name}
+		// do not log the telemetry health check timer ticks since this
+		// log statement is called once for every internal heartbeat
+		// check, which is a bit excessive under DEBUG logging [GH-556]
+		if event.Source != "containerpilot.heartbeat" {
+			log.Debugf("timer: %v", event)
+		}
+		send(rx, event)
+	})
+}
+
+// NewJitteredEventTimer starts a timer on the clock that will send a
+// TimerExpired event every time the timer expires. The first event is
+// delayed by the additional offset, and each tick is randomly lengthened
+// or shortened by up to the jitter, so that many processes started at the
+// same time don't fire their timers in lockstep.
+func NewJitteredEventTimer(
+	ctx context.Context,
+	clock Clock,
+	rx EventReceiver,
+	tick time.Duration,
+	offset time.Duration,
+	jitter time.Duration,
+	name string,
+) {
+	var fire func(error)
+	fire = func(err error) {
+		if err != nil {
+			return
+		}
+		event := Event{Code: TimerExpired, Source: name}
+		log.Debugf("timer: %v", event)
+		send(rx, event)
+		clock.AfterFunc(ctx, tick+RandomDuration(2*jitter)-jitter, fire)
+	}
+	clock.AfterFunc(ctx, tick+offset, fire)
+}
+
+// Schedule is implemented by anything that can compute the next time an
+// event should fire after the given time, such as a cron expression. A
+// zero time means the Schedule will never fire again.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// NewEventSchedule starts a timer on the clock that will send a
+// TimerExpired event every time the Schedule comes due
+func NewEventSchedule(
+	ctx context.Context,
+	clock Clock,
+	rx EventReceiver,
+	schedule Schedule,
+	name string,
+) {
+	var wait func()
+	wait = func() {
+		now := clock.Now()
+		next := schedule.Next(now)
+		if next.IsZero() {
+			log.Debugf("schedule: %s will not fire again", name)
+			return
+		}
+		clock.AfterFunc(ctx, next.Sub(now), func(err error) {
+			if err != nil {
 				return
-			case <-ticker.C:
-				event := Event{Code: TimerExpired, Source: name}
-				// do not log the telemetry health check timer ticks since this
-				// log statement is called once for every internal heartbeat
-				// check, which is a bit excessive under DEBUG logging [GH-556]
-				if event.Source != "containerpilot.heartbeat" {
-					log.Debugf("timer: %v", event)
-				}
-				rx <- event
 			}
-		}
-	}()
+			event := Event{Code: TimerExpired, Source: name}
+			log.Debugf("schedule: %v", event)
+			send(rx, event)
+			wait()
+		})
+	}
+	wait()
 }
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+type neverSchedule struct{}
+
+func (s neverSchedule) Next(t time.Time) time.Time {
+	return time.Time{}
+}
+
+func TestEventSchedule(t *testing.T) {
+	rx := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	NewEventSchedule(ctx, WallClock{}, &Subscriber{Rx: rx}, everySchedule(20*time.Millisecond), "sched")
+	time.Sleep(110 * time.Millisecond)
+	cancel()
+	got := len(rx)
+	if got < 3 || got > 5 {
+		t.Fatalf("expected ~5 schedule events but got %d", got)
+	}
+	expected := Event{Code: TimerExpired, Source: "sched"}
+	if event := <-rx; event != expected {
+		t.Fatalf("expected %v but got %v", expected, event)
+	}
+}
+
+func TestEventScheduleNever(t *testing.T) {
+	rx := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	NewEventSchedule(ctx, WallClock{}, &Subscriber{Rx: rx}, neverSchedule{}, "sched")
+	time.Sleep(50 * time.Millisecond)
+	if len(rx) != 0 {
+		t.Fatalf("expected no schedule events but got %d", len(rx))
+	}
+}
+
+func TestRandomDurationSeeded(t *testing.T) {
+	SeedRandom(42)
+	first := []time.Duration{}
+	for i := 0; i < 5; i++ {
+		first = append(first, RandomDuration(time.Minute))
+	}
+	SeedRandom(42)
+	for i := 0; i < 5; i++ {
+		got := RandomDuration(time.Minute)
+		if got != first[i] {
+			t.Fatalf("expected seeded duration %v but got %v", first[i], got)
+		}
+		if got < 0 || got >= time.Minute {
+			t.Fatalf("expected duration in [0, 1m) but got %v", got)
+		}
+	}
+	if got := RandomDuration(0); got != 0 {
+		t.Fatalf("expected 0 for zero max but got %v", got)
+	}
+}
+
+func TestJitteredEventTimer(t *testing.T) {
+	rx := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	NewJitteredEventTimer(ctx, WallClock{}, &Subscriber{Rx: rx}, 20*time.Millisecond,
+		100*time.Millisecond, 5*time.Millisecond, "jittered")
+	time.Sleep(60 * time.Millisecond)
+	if len(rx) != 0 {
+		t.Fatalf("expected first tick to be delayed by offset but got %d", len(rx))
+	}
+	time.Sleep(130 * time.Millisecond)
+	cancel()
+	if got := len(rx); got < 2 || got > 5 {
+		t.Fatalf("expected ~3 timer events but got %d", got)
+	}
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"math/rand"
+	"time"
+)
+
+// restartBackoff computes the delay before each restart of a Job's exec.
+// The delay grows by 'multiplier' after each restart up to 'maxDelay', and
+// goes back to the initial delay once the exec has stayed up for at least
+// 'resetAfter'.
+type restartBackoff struct {
+	delay      time.Duration
+	multiplier float64
+	maxDelay   time.Duration
+	jitter     float64 // fraction of the delay, from 0 to 1
+	resetAfter time.Duration
+
+	nextDelay time.Duration
+	startedAt time.Time
+	random    func() float64
+}
+
+func newRestartBackoff(cfg *Config) *restartBackoff {
+	if cfg.restartDelay == 0 {
+		return nil
+	}
+	return &restartBackoff{
+		delay:      cfg.restartDelay,
+		multiplier: cfg.restartMultiplier,
+		maxDelay:   cfg.restartMaxDelay,
+		jitter:     cfg.restartJitter,
+		resetAfter: cfg.restartResetAfter,
+		nextDelay:  cfg.restartDelay,
+		random:     rand.Float64,
+	}
+}
+
+// started records the time the Job's exec was started
+func (b *restartBackoff) started(now time.Time) {
+	b.startedAt = now
+}
+
+// next returns the delay before restarting an exec that exited at 'now'
+// and increases the delay for the following restart
+func (b *restartBackoff) next(now time.Time) time.Duration {
+	if b.resetAfter > 0 && !b.startedAt.IsZero() &&
+		now.Sub(b.startedAt) >= b.resetAfter {
+		b.nextDelay = b.delay
+	}
+	delay := b.nextDelay
+	b.nextDelay = time.Duration(float64(b.nextDelay) * b.multiplier)
+	if b.maxDelay > 0 && b.nextDelay > b.maxDelay {
+		b.nextDelay = b.maxDelay
+	}
+	if b.jitter > 0 {
+		// spread the delay evenly across +/- jitter
+		delay += time.Duration(float64(delay) * b.jitter * (2*b.random() - 1))
+	}
+	return delay
+}
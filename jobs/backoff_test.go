@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	cfg := &Config{
+		restartDelay:      time.Second,
+		restartMultiplier: 2,
+		restartMaxDelay:   5 * time.Second,
+		restartResetAfter: time.Minute,
+	}
+	b := newRestartBackoff(cfg)
+	now := time.Now()
+	restart := func(runtime time.Duration) time.Duration {
+		b.started(now)
+		now = now.Add(runtime)
+		return b.next(now)
+	}
+	assert.Equal(t, time.Second, restart(time.Millisecond))
+	assert.Equal(t, 2*time.Second, restart(time.Millisecond))
+	assert.Equal(t, 4*time.Second, restart(time.Millisecond))
+	assert.Equal(t, 5*time.Second, restart(time.Millisecond), "capped at maxDelay")
+	assert.Equal(t, 5*time.Second, restart(time.Millisecond), "capped at maxDelay")
+	assert.Equal(t, time.Second, restart(time.Minute), "reset after stable runtime")
+	assert.Equal(t, 2*time.Second, restart(time.Millisecond))
+}
+
+func TestRestartBackoffJitter(t *testing.T) {
+	cfg := &Config{
+		restartDelay:      10 * time.Second,
+		restartMultiplier: 1,
+		restartJitter:     0.5,
+	}
+	b := newRestartBackoff(cfg)
+	b.random = func() float64 { return 0 }
+	assert.Equal(t, 5*time.Second, b.next(time.Now()))
+	b.random = func() float64 { return 1 }
+	assert.Equal(t, 15*time.Second, b.next(time.Now()))
+}
+
+func TestRestartBackoffDisabled(t *testing.T) {
+	assert.Nil(t, newRestartBackoff(&Config{}))
+}
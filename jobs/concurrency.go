@@ -0,0 +1,11 @@
+package jobs
+
+// concurrencyPolicy determines what a periodic Job does when its timer
+// fires while the previous run of its exec is still running
+type concurrencyPolicy string
+
+const (
+	concurrencySkip    concurrencyPolicy = "skip"    // drop the run
+	concurrencyQueue   concurrencyPolicy = "queue"   // run once afterwards
+	concurrencyReplace concurrencyPolicy = "replace" // terminate and restart
+)
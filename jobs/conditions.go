@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"path"
+	"strings"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+// whenConditions tracks which of the conditions in a Job's 'when.all' or
+// 'when.any' are satisfied by the events the Job has received. A condition
+// stays satisfied until the opposite event is seen from the same source
+// (ex. 'unhealthy' clears 'healthy').
+type whenConditions struct {
+	conditions []events.Event
+	satisfied  []bool
+	all        bool
+}
+
+func newWhenConditions(cfg *Config) *whenConditions {
+	if len(cfg.whenConditions) == 0 {
+		return nil
+	}
+	return &whenConditions{
+		conditions: cfg.whenConditions,
+		satisfied:  make([]bool, len(cfg.whenConditions)),
+		all:        cfg.whenAll,
+	}
+}
+
+// conditionOpposites maps the event of a condition to the event that
+// clears it again. Events that aren't state, like 'changed', have no
+// opposite.
+var conditionOpposites = map[events.EventCode]events.EventCode{
+	events.StatusHealthy:    events.StatusUnhealthy,
+	events.StatusUnhealthy:  events.StatusHealthy,
+	events.ExitSuccess:      events.ExitFailed,
+	events.ExitFailed:       events.ExitSuccess,
+	events.EnterMaintenance: events.ExitMaintenance,
+	events.ExitMaintenance:  events.EnterMaintenance,
+}
+
+// update records the event against the conditions and reports whether
+// the combined condition is satisfied as a result of this event
+func (c *whenConditions) update(event events.Event) bool {
+	matched := false
+	for i, condition := range c.conditions {
+		if eventMatches(condition, event) {
+			c.satisfied[i] = true
+			matched = true
+			continue
+		}
+		if opposite, ok := conditionOpposites[condition.Code]; ok &&
+			eventMatches(events.Event{Code: opposite, Source: condition.Source}, event) {
+			c.satisfied[i] = false
+		}
+	}
+	if !matched {
+		return false
+	}
+	for _, ok := range c.satisfied {
+		if ok && !c.all {
+			return true
+		}
+		if !ok && c.all {
+			return false
+		}
+	}
+	return c.all
+}
+
+// eventMatches reports whether the event matches the wanted event code and
+// source, where the wanted source may be a glob pattern (ex. "watch.api-*")
+func eventMatches(want, event events.Event) bool {
+	if want.Code != event.Code || want == events.NonEvent {
+		return false
+	}
+	if want.Source == event.Source {
+		return true
+	}
+	return sourceMatches(want.Source, event.Source)
+}
+
+// sourceMatches reports whether the glob pattern matches the source. The
+// sources that ContainerPilot publishes on its own, like health checks
+// ("check.db"), signals, and "global", only match a pattern that starts
+// with the same prefix (ex. "check.*"), so that a pattern like "*"
+// matches only the jobs and watches.
+func sourceMatches(pattern, source string) bool {
+	if prefix := internalPrefix(source); prefix != "" &&
+		!strings.HasPrefix(pattern, prefix) {
+		return false
+	}
+	matched, _ := path.Match(pattern, source)
+	return matched
+}
+
+func internalPrefix(source string) string {
+	switch {
+	case strings.HasPrefix(source, "check."):
+		return "check."
+	case source == "SIGHUP" || source == "SIGUSR2":
+		return "SIG"
+	case source == "global":
+		return "global"
+	}
+	return ""
+}
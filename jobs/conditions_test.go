@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+func TestWhenConditions(t *testing.T) {
+	dbHealthy := events.Event{Code: events.StatusHealthy, Source: "db"}
+	migrated := events.Event{Code: events.ExitSuccess, Source: "migrations"}
+	other := events.Event{Code: events.StatusHealthy, Source: "other"}
+	cfg := &Config{whenConditions: []events.Event{dbHealthy, migrated}}
+
+	assert.Nil(t, newWhenConditions(&Config{}))
+
+	cfg.whenAll = true
+	all := newWhenConditions(cfg)
+	assert.False(t, all.update(dbHealthy))
+	assert.False(t, all.update(other))
+	assert.False(t, all.update(dbHealthy), "repeated events don't count twice")
+	assert.True(t, all.update(migrated))
+
+	// a condition that flips back before the last one arrives is no
+	// longer satisfied
+	all = newWhenConditions(cfg)
+	assert.False(t, all.update(dbHealthy))
+	assert.False(t, all.update(events.Event{Code: events.StatusUnhealthy, Source: "db"}))
+	assert.False(t, all.update(migrated), "db is unhealthy again")
+	assert.True(t, all.update(dbHealthy))
+
+	all = newWhenConditions(cfg)
+	assert.False(t, all.update(migrated))
+	assert.False(t, all.update(events.Event{Code: events.ExitFailed, Source: "migrations"}))
+	assert.False(t, all.update(dbHealthy), "latest migrations run failed")
+
+	cfg.whenAll = false
+	any := newWhenConditions(cfg)
+	assert.False(t, any.update(other))
+	assert.True(t, any.update(migrated))
+}
+
+func TestEventMatches(t *testing.T) {
+	changed := func(source string) events.Event {
+		return events.Event{Code: events.StatusChanged, Source: source}
+	}
+	assert.True(t, eventMatches(changed("watch.api"), changed("watch.api")))
+	assert.True(t, eventMatches(changed("watch.api-*"), changed("watch.api-1")))
+	assert.True(t, eventMatches(changed("*"), changed("watch.api")))
+	assert.False(t, eventMatches(changed("watch.api-*"), changed("watch.web")))
+	assert.False(t, eventMatches(changed("watch.api-*"),
+		events.Event{Code: events.ExitFailed, Source: "watch.api-1"}))
+	assert.False(t, eventMatches(events.NonEvent, events.NonEvent))
+
+	// internal sources only match patterns that name them
+	failed := func(source string) events.Event {
+		return events.Event{Code: events.ExitFailed, Source: source}
+	}
+	assert.True(t, eventMatches(failed("*"), failed("db")))
+	assert.False(t, eventMatches(failed("*"), failed("check.db")))
+	assert.True(t, eventMatches(failed("check.*"), failed("check.db")))
+	assert.True(t, eventMatches(failed("check.db"), failed("check.db")))
+	assert.False(t, eventMatches(changed("*"), changed("global")))
+	assert.False(t, eventMatches(changed("*"), changed("SIGHUP")))
+}
@@ -3,9 +3,11 @@ package jobs
 import (
 	"fmt"
 	"os"
+	"path"
 	"strconv"
 	"time"
 
+	"github.com/joyent/containerpilot/checks"
 	"github.com/joyent/containerpilot/commands"
 	"github.com/joyent/containerpilot/config/decode"
 	"github.com/joyent/containerpilot/config/services"
@@ -17,6 +19,14 @@ import (
 
 const taskMinDuration = time.Millisecond
 
+// Consul truncates check output to 4KB by default, so there's no point
+// in capturing more than that from a health check exec
+const defaultHealthOutputLimit = 4096
+
+// health check execs that exit with these codes mark the job as
+// degraded rather than unhealthy, unless the job sets warningExitCodes
+var defaultWarningExitCodes = []int{1}
+
 // Config holds the configuration for service discovery data
 type Config struct {
 	Name string      `mapstructure:"name"`
@@ -31,26 +41,49 @@ type Config struct {
 	serviceDefinition *discovery.ServiceDefinition
 
 	// health checking
-	Health            *HealthConfig `mapstructure:"health"`
-	healthCheckExec   *commands.Command
-	heartbeatInterval time.Duration
-	ttl               int
+	Health             *HealthConfig `mapstructure:"health"`
+	healthCheckExec    *commands.Command
+	healthCheck        healthChecker
+	healthCheckTimeout time.Duration
+	heartbeatInterval  time.Duration
+	ttl                int
+	healthRise         int
+	healthFall         int
+	healthStartPeriod  time.Duration
+	warningExitCodes   []int
+	flapWindow         time.Duration
+	flapTransitions    int
 
 	// timeouts and restarts
-	ExecTimeout     string      `mapstructure:"timeout"`
-	Restarts        interface{} `mapstructure:"restarts"`
-	StopTimeout     string      `mapstructure:"stopTimeout"`
-	execTimeout     time.Duration
-	exec            *commands.Command
-	stoppingTimeout time.Duration
-	restartLimit    int
-	freqInterval    time.Duration
+	ExecTimeout       string               `mapstructure:"timeout"`
+	Restarts          interface{}          `mapstructure:"restarts"`
+	RestartPolicy     *RestartPolicyConfig `mapstructure:"restartPolicy"`
+	StopTimeout       string               `mapstructure:"stopTimeout"`
+	execTimeout       time.Duration
+	exec              *commands.Command
+	stoppingTimeout   time.Duration
+	restartLimit      int
+	restartDelay      time.Duration
+	restartMultiplier float64
+	restartMaxDelay   time.Duration
+	restartJitter     float64
+	restartResetAfter time.Duration
+	freqInterval      time.Duration
+	freqSplay         time.Duration
+	freqJitter        time.Duration
+	schedule          *cronSchedule
+	concurrency       concurrencyPolicy
 
 	// related jobs and frequency
 	When              *WhenConfig `mapstructure:"when"`
 	whenEvent         events.Event
 	whenTimeout       time.Duration
 	whenStartsLimit   int
+	whenConditions    []events.Event
+	whenAll           bool
+	whenDebounce      time.Duration
+	whenThrottle      time.Duration
+	whenDelay         time.Duration
 	stoppingWaitEvent events.Event
 
 	// logging
@@ -60,20 +93,65 @@ type Config struct {
 // WhenConfig determines when a Job runs (dependencies on other Jobs,
 // Watches, or frequency timers)
 type WhenConfig struct {
-	Frequency string `mapstructure:"interval"`
-	Source    string `mapstructure:"source"`
-	Once      string `mapstructure:"once"`
-	Each      string `mapstructure:"each"`
-	Timeout   string `mapstructure:"timeout"`
+	Frequency   string `mapstructure:"interval"`
+	Splay       string `mapstructure:"splay"`
+	Jitter      string `mapstructure:"jitter"`
+	Cron        string `mapstructure:"cron"`
+	Timezone    string `mapstructure:"timezone"`
+	Concurrency string `mapstructure:"concurrency"`
+	Source      string `mapstructure:"source"`
+	Once        string `mapstructure:"once"`
+	Each        string `mapstructure:"each"`
+	Timeout     string `mapstructure:"timeout"`
+	Debounce    string `mapstructure:"debounce"`
+	Throttle    string `mapstructure:"throttle"`
+	Delay       string `mapstructure:"delay"`
+
+	All []ConditionConfig `mapstructure:"all"`
+	Any []ConditionConfig `mapstructure:"any"`
+}
+
+// ConditionConfig is one of the conditions in a Job's 'when.all' or
+// 'when.any' list: an event from a particular source
+type ConditionConfig struct {
+	Source string `mapstructure:"source"`
+	Event  string `mapstructure:"event"`
 }
 
 // HealthConfig configures the Job's health checks
 type HealthConfig struct {
-	CheckExec    interface{}    `mapstructure:"exec"`
-	CheckTimeout string         `mapstructure:"timeout"`
-	Heartbeat    int            `mapstructure:"interval"` // time in seconds
-	TTL          int            `mapstructure:"ttl"`      // time in seconds
-	Logging      *LoggingConfig `mapstructure:"logging"`
+	CheckExec    interface{}        `mapstructure:"exec"`
+	CheckHTTP    *checks.HTTPConfig `mapstructure:"http"`
+	CheckTCP     *checks.TCPConfig  `mapstructure:"tcp"`
+	CheckGRPC    *checks.GRPCConfig `mapstructure:"grpc"`
+	CheckTimeout string             `mapstructure:"timeout"`
+	Heartbeat    int                `mapstructure:"interval"` // time in seconds
+	TTL          int                `mapstructure:"ttl"`      // time in seconds
+	Rise         int                `mapstructure:"rise"`
+	Fall         int                `mapstructure:"fall"`
+	StartPeriod  string             `mapstructure:"startPeriod"`
+	WarningCodes []int              `mapstructure:"warningExitCodes"`
+	OutputLimit  int                `mapstructure:"outputLimit"` // bytes
+	Flap         *FlapConfig        `mapstructure:"flap"`
+	Logging      *LoggingConfig     `mapstructure:"logging"`
+}
+
+// FlapConfig configures flap detection for the Job's health checks. If
+// the check result changes at least 'transitions' times within 'window',
+// the Job holds its last stable status until the check settles down.
+type FlapConfig struct {
+	Window      string `mapstructure:"window"`
+	Transitions int    `mapstructure:"transitions"`
+}
+
+// RestartPolicyConfig configures an exponential backoff between restarts
+// of the Job's exec
+type RestartPolicyConfig struct {
+	Delay      string  `mapstructure:"delay"`
+	Multiplier float64 `mapstructure:"multiplier"`
+	MaxDelay   string  `mapstructure:"maxDelay"`
+	Jitter     float64 `mapstructure:"jitter"`
+	ResetAfter string  `mapstructure:"resetAfter"`
 }
 
 // ConsulExtras handles additional Consul configuration.
@@ -114,6 +192,26 @@ func NewConfigs(raw []interface{}, disc discovery.Backend) ([]*Config, error) {
 	return jobs, nil
 }
 
+// NewConfig parses the json config for a single job into a validated
+// Config, for a job added after the rest of the jobs are already running.
+// Unlike NewConfigs, it can't set up a "stopping" dependency on one of
+// the running jobs, so a job that starts on a "stopping" event is invalid.
+func NewConfig(raw map[string]interface{}, disc discovery.Backend) (*Config, error) {
+	cfg := &Config{}
+	if err := decode.ToStruct(raw, cfg); err != nil {
+		return nil, fmt.Errorf("job configuration error: %v", err)
+	}
+	if err := cfg.Validate(disc); err != nil {
+		return nil, err
+	}
+	if cfg.whenEvent.Code == events.Stopping {
+		return nil, fmt.Errorf(
+			"job[%s].when 'stopping' can't be used for a job added to running jobs",
+			cfg.Name)
+	}
+	return cfg, nil
+}
+
 // Validate ensures that a Config meets all constraints
 func (cfg *Config) Validate(disc discovery.Backend) error {
 	if err := cfg.validateDiscovery(disc); err != nil {
@@ -128,6 +226,9 @@ func (cfg *Config) Validate(disc discovery.Backend) error {
 	if err := cfg.validateRestarts(); err != nil {
 		return err
 	}
+	if err := cfg.validateRestartPolicy(); err != nil {
+		return err
+	}
 
 	return cfg.validateExec()
 }
@@ -136,6 +237,13 @@ func (cfg *Config) setStopping(name string) {
 	cfg.stoppingWaitEvent = events.Event{events.Stopped, name}
 }
 
+// StoppingDependent returns the name of the job that runs on this job's
+// "stopping" event, which this job waits for before it stops, or "" if
+// there's no such job
+func (cfg *Config) StoppingDependent() string {
+	return cfg.stoppingWaitEvent.Source
+}
+
 func (cfg *Config) validateDiscovery(disc discovery.Backend) error {
 	// setting up discovery requires the TTL from the health check first
 	if err := cfg.validateHealthCheck(); err != nil {
@@ -185,15 +293,48 @@ func (cfg *Config) validateWhen() error {
 		return nil
 	}
 
-	if (cfg.When.Frequency != "" && cfg.When.Once != "") ||
-		(cfg.When.Frequency != "" && cfg.When.Each != "") ||
-		(cfg.When.Once != "" && cfg.When.Each != "") {
-		return fmt.Errorf("job[%s].when can have only one of 'interval', 'once', or 'each'",
+	whenCount := 0
+	for _, isSet := range []bool{
+		cfg.When.Frequency != "",
+		cfg.When.Cron != "",
+		cfg.When.Once != "",
+		cfg.When.Each != "",
+		len(cfg.When.All) > 0,
+		len(cfg.When.Any) > 0,
+	} {
+		if isSet {
+			whenCount++
+		}
+	}
+	if whenCount > 1 {
+		return fmt.Errorf("job[%s].when can have only one of 'interval', 'cron', 'once', 'each', 'all', or 'any'",
 			cfg.Name)
 	}
+	if (cfg.When.Splay != "" || cfg.When.Jitter != "") && cfg.When.Frequency == "" {
+		return fmt.Errorf("job[%s].when.splay and job[%s].when.jitter require 'interval'",
+			cfg.Name, cfg.Name)
+	}
+	if cfg.When.Timezone != "" && cfg.When.Cron == "" {
+		return fmt.Errorf("job[%s].when.timezone requires 'cron'", cfg.Name)
+	}
+	if err := cfg.validateConcurrency(); err != nil {
+		return err
+	}
+	if err := cfg.validateTriggerLimits(); err != nil {
+		return err
+	}
+	if err := cfg.validateDelay(); err != nil {
+		return err
+	}
 	if cfg.When.Frequency != "" {
 		return cfg.validateFrequency()
 	}
+	if cfg.When.Cron != "" {
+		return cfg.validateCron()
+	}
+	if len(cfg.When.All) > 0 || len(cfg.When.Any) > 0 {
+		return cfg.validateWhenConditions()
+	}
 	return cfg.validateWhenEvent()
 }
 
@@ -208,12 +349,163 @@ func (cfg *Config) validateFrequency() error {
 			cfg.Name, cfg.When.Frequency, taskMinDuration)
 	}
 	cfg.freqInterval = freq
+
+	splay, err := timing.GetTimeout(cfg.When.Splay)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.splay '%s': %v",
+			cfg.Name, cfg.When.Splay, err)
+	}
+	if splay < 0 {
+		return fmt.Errorf("job[%s].when.splay '%s' cannot be negative",
+			cfg.Name, cfg.When.Splay)
+	}
+	cfg.freqSplay = splay
+
+	jitter, err := timing.GetTimeout(cfg.When.Jitter)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.jitter '%s': %v",
+			cfg.Name, cfg.When.Jitter, err)
+	}
+	if jitter < 0 || jitter >= freq {
+		return fmt.Errorf("job[%s].when.jitter '%s' must be less than the interval '%s'",
+			cfg.Name, cfg.When.Jitter, cfg.When.Frequency)
+	}
+	cfg.freqJitter = jitter
+
 	cfg.whenTimeout = time.Duration(0)
 	cfg.whenEvent = events.GlobalStartup
 	cfg.whenStartsLimit = 1
 	return nil
 }
 
+func (cfg *Config) validateCron() error {
+	schedule, err := parseCron(cfg.When.Cron, cfg.When.Timezone)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.cron '%s': %v",
+			cfg.Name, cfg.When.Cron, err)
+	}
+	cfg.schedule = schedule
+	cfg.whenTimeout = time.Duration(0)
+	// unlike an interval, a cron schedule doesn't run the job at startup
+	cfg.whenEvent = events.NonEvent
+	cfg.whenStartsLimit = 0
+	return nil
+}
+
+func (cfg *Config) validateConcurrency() error {
+	if cfg.When.Concurrency == "" {
+		cfg.concurrency = concurrencyQueue
+		return nil
+	}
+	if cfg.When.Frequency == "" && cfg.When.Cron == "" {
+		return fmt.Errorf("job[%s].when.concurrency requires 'interval' or 'cron'",
+			cfg.Name)
+	}
+	switch policy := concurrencyPolicy(cfg.When.Concurrency); policy {
+	case concurrencySkip, concurrencyQueue, concurrencyReplace:
+		cfg.concurrency = policy
+	default:
+		return fmt.Errorf("job[%s].when.concurrency must be one of 'skip', 'queue', or 'replace'",
+			cfg.Name)
+	}
+	return nil
+}
+
+func (cfg *Config) validateTriggerLimits() error {
+	if cfg.When.Debounce == "" && cfg.When.Throttle == "" {
+		return nil
+	}
+	// a job triggered by a signal starts on each signal
+	isSignal := cfg.When.Source == "SIGHUP" || cfg.When.Source == "SIGUSR2"
+	if cfg.When.Each == "" && !isSignal {
+		return fmt.Errorf("job[%s].when.debounce and job[%s].when.throttle require 'each'",
+			cfg.Name, cfg.Name)
+	}
+	debounce, err := timing.GetTimeout(cfg.When.Debounce)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.debounce '%s': %v",
+			cfg.Name, cfg.When.Debounce, err)
+	}
+	if debounce < 0 {
+		return fmt.Errorf("job[%s].when.debounce '%s' cannot be negative",
+			cfg.Name, cfg.When.Debounce)
+	}
+	cfg.whenDebounce = debounce
+
+	throttle, err := timing.GetTimeout(cfg.When.Throttle)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.throttle '%s': %v",
+			cfg.Name, cfg.When.Throttle, err)
+	}
+	if throttle < 0 {
+		return fmt.Errorf("job[%s].when.throttle '%s' cannot be negative",
+			cfg.Name, cfg.When.Throttle)
+	}
+	cfg.whenThrottle = throttle
+	return nil
+}
+
+func (cfg *Config) validateDelay() error {
+	if cfg.When.Delay == "" {
+		return nil
+	}
+	if cfg.When.Frequency != "" || cfg.When.Cron != "" {
+		return fmt.Errorf("job[%s].when.delay cannot be used with 'interval' or 'cron'",
+			cfg.Name)
+	}
+	delay, err := timing.GetTimeout(cfg.When.Delay)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.delay '%s': %v",
+			cfg.Name, cfg.When.Delay, err)
+	}
+	if delay < 0 {
+		return fmt.Errorf("job[%s].when.delay '%s' cannot be negative",
+			cfg.Name, cfg.When.Delay)
+	}
+	cfg.whenDelay = delay
+	return nil
+}
+
+func (cfg *Config) validateWhenConditions() error {
+	field, conditions := "all", cfg.When.All
+	if len(cfg.When.Any) > 0 {
+		field, conditions = "any", cfg.When.Any
+	}
+	if cfg.When.Source != "" {
+		return fmt.Errorf("job[%s].when.source cannot be used with 'all' or 'any'",
+			cfg.Name)
+	}
+	whenTimeout, err := timing.GetTimeout(cfg.When.Timeout)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.timeout: %v",
+			cfg.Name, err)
+	}
+	cfg.whenTimeout = whenTimeout
+
+	for i, condition := range conditions {
+		if condition.Source == "" {
+			return fmt.Errorf("job[%s].when.%s[%d].source must not be blank",
+				cfg.Name, field, i)
+		}
+		if _, err := path.Match(condition.Source, ""); err != nil {
+			return fmt.Errorf("unable to parse job[%s].when.%s[%d].source '%s': %v",
+				cfg.Name, field, i, condition.Source, err)
+		}
+		eventCode, err := events.FromString(condition.Event)
+		if err != nil {
+			return fmt.Errorf("unable to parse job[%s].when.%s[%d].event: %v",
+				cfg.Name, field, i, err)
+		}
+		cfg.whenConditions = append(cfg.whenConditions,
+			events.Event{Code: eventCode, Source: condition.Source})
+	}
+	cfg.whenAll = field == "all"
+	// the job's start isn't a single event but the combined condition
+	cfg.whenEvent = events.NonEvent
+	cfg.whenStartsLimit = 1
+	return nil
+}
+
 func (cfg *Config) validateWhenEvent() error {
 	whenTimeout, err := timing.GetTimeout(cfg.When.Timeout)
 	if err != nil {
@@ -236,6 +528,11 @@ func (cfg *Config) validateWhenEvent() error {
 			cfg.Name, err)
 	}
 
+	if _, err := path.Match(cfg.When.Source, ""); err != nil {
+		return fmt.Errorf("unable to parse job[%s].when.source '%s': %v",
+			cfg.Name, cfg.When.Source, err)
+	}
+
 	if cfg.When.Source == "SIGHUP" || cfg.When.Source == "SIGUSR2" {
 		eventCode = events.Signal
 		cfg.whenStartsLimit = unlimited
@@ -310,6 +607,9 @@ func (cfg *Config) validateHealthCheck() error {
 
 	cfg.ttl = cfg.Health.TTL
 	cfg.heartbeatInterval = time.Duration(cfg.Health.Heartbeat) * time.Second
+	if err := cfg.validateHealthThresholds(); err != nil {
+		return err
+	}
 
 	var checkTimeout time.Duration
 	if cfg.Health.CheckTimeout != "" {
@@ -322,10 +622,53 @@ func (cfg *Config) validateHealthCheck() error {
 	} else {
 		checkTimeout = cfg.heartbeatInterval
 	}
+	cfg.healthCheckTimeout = checkTimeout
+
+	startPeriod, err := timing.GetTimeout(cfg.Health.StartPeriod)
+	if err != nil {
+		return fmt.Errorf("could not parse job[%s].health.startPeriod '%s': %v",
+			cfg.Name, cfg.Health.StartPeriod, err)
+	}
+	if startPeriod < 0 {
+		return fmt.Errorf("job[%s].health.startPeriod '%s' cannot be negative",
+			cfg.Name, cfg.Health.StartPeriod)
+	}
+	cfg.healthStartPeriod = startPeriod
+
+	if len(cfg.Health.WarningCodes) > 0 && cfg.Health.CheckExec == nil {
+		return fmt.Errorf("job[%s].health.warningExitCodes requires 'exec'",
+			cfg.Name)
+	}
 
-	if cfg.Health.CheckExec != nil {
-		// the telemetry service won't have a health check
-		checkName := "check." + cfg.Name
+	if cfg.Health.OutputLimit < 0 {
+		return fmt.Errorf("job[%s].health.outputLimit cannot be negative",
+			cfg.Name)
+	}
+	outputLimit := cfg.Health.OutputLimit
+	if outputLimit == 0 {
+		outputLimit = defaultHealthOutputLimit
+	}
+
+	checkCount := 0
+	for _, isSet := range []bool{
+		cfg.Health.CheckExec != nil,
+		cfg.Health.CheckHTTP != nil,
+		cfg.Health.CheckTCP != nil,
+		cfg.Health.CheckGRPC != nil,
+	} {
+		if isSet {
+			checkCount++
+		}
+	}
+	if checkCount > 1 {
+		return fmt.Errorf("job[%s].health can have only one of 'exec', 'http', 'tcp', or 'grpc'",
+			cfg.Name)
+	}
+
+	// the telemetry service won't have a health check
+	checkName := "check." + cfg.Name
+	switch {
+	case cfg.Health.CheckExec != nil:
 		fields := log.Fields{"check": checkName}
 		if cfg.Health.Logging != nil && cfg.Health.Logging.Raw {
 			fields = nil
@@ -338,16 +681,85 @@ func (cfg *Config) validateHealthCheck() error {
 				cfg.Name, err)
 		}
 		cmd.Name = checkName
+		cmd.OutputLimit = outputLimit
 		cfg.healthCheckExec = cmd
+		cfg.healthCheck = cmd
+		for _, code := range cfg.Health.WarningCodes {
+			if code < 1 || code > 255 {
+				return fmt.Errorf("job[%s].health.warningExitCodes '%d' must be between 1 and 255",
+					cfg.Name, code)
+			}
+		}
+		cfg.warningExitCodes = cfg.Health.WarningCodes
+		if cfg.Health.WarningCodes == nil {
+			// the Nagios plugin convention: 1 is a warning, and 2 or
+			// higher is critical
+			cfg.warningExitCodes = defaultWarningExitCodes
+		}
+	case cfg.Health.CheckHTTP != nil:
+		check, err := checks.NewHTTPCheck(checkName, cfg.Health.CheckHTTP, checkTimeout)
+		if err != nil {
+			return fmt.Errorf("unable to create job[%s].health.http: %v",
+				cfg.Name, err)
+		}
+		cfg.healthCheck = check
+	case cfg.Health.CheckTCP != nil:
+		check, err := checks.NewTCPCheck(checkName, cfg.Health.CheckTCP, checkTimeout)
+		if err != nil {
+			return fmt.Errorf("unable to create job[%s].health.tcp: %v",
+				cfg.Name, err)
+		}
+		cfg.healthCheck = check
+	case cfg.Health.CheckGRPC != nil:
+		check, err := checks.NewGRPCCheck(checkName, cfg.Health.CheckGRPC, checkTimeout)
+		if err != nil {
+			return fmt.Errorf("unable to create job[%s].health.grpc: %v",
+				cfg.Name, err)
+		}
+		cfg.healthCheck = check
 	}
 	return nil
 }
 
+func (cfg *Config) validateHealthThresholds() error {
+	// rise and fall default to 1, so that a single result changes status
+	if cfg.Health.Rise < 0 {
+		return fmt.Errorf("job[%s].health.rise must be >= 0", cfg.Name)
+	}
+	if cfg.Health.Fall < 0 {
+		return fmt.Errorf("job[%s].health.fall must be >= 0", cfg.Name)
+	}
+	cfg.healthRise = threshold(cfg.Health.Rise)
+	cfg.healthFall = threshold(cfg.Health.Fall)
+
+	if cfg.Health.Flap == nil {
+		return nil
+	}
+	if cfg.Health.Flap.Window == "" {
+		return fmt.Errorf("job[%s].health.flap.window must be set", cfg.Name)
+	}
+	window, err := timing.ParseDuration(cfg.Health.Flap.Window)
+	if err != nil {
+		return fmt.Errorf("unable to parse job[%s].health.flap.window '%s': %v",
+			cfg.Name, cfg.Health.Flap.Window, err)
+	}
+	if window <= 0 {
+		return fmt.Errorf("job[%s].health.flap.window must be > 0", cfg.Name)
+	}
+	if cfg.Health.Flap.Transitions < 2 {
+		return fmt.Errorf("job[%s].health.flap.transitions must be >= 2",
+			cfg.Name)
+	}
+	cfg.flapWindow = window
+	cfg.flapTransitions = cfg.Health.Flap.Transitions
+	return nil
+}
+
 func (cfg *Config) validateRestarts() error {
 
 	// defaults if omitted
 	if cfg.Restarts == nil {
-		if cfg.freqInterval != time.Duration(0) {
+		if cfg.freqInterval != time.Duration(0) || cfg.schedule != nil {
 			cfg.restartLimit = unlimited
 		} else {
 			cfg.restartLimit = 0
@@ -395,6 +807,69 @@ func (cfg *Config) validateRestarts() error {
 	return nil
 }
 
+// restart policy defaults, used for any fields omitted from the
+// restartPolicy block
+const (
+	defaultRestartDelay      = time.Second
+	defaultRestartMultiplier = 2.0
+	defaultRestartMaxDelay   = time.Minute
+)
+
+func (cfg *Config) validateRestartPolicy() error {
+	policy := cfg.RestartPolicy
+	if policy == nil {
+		return nil // restart immediately
+	}
+	parse := func(field, val string, defaultVal time.Duration) (time.Duration, error) {
+		if val == "" {
+			return defaultVal, nil
+		}
+		d, err := timing.ParseDuration(val)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse job[%s].restartPolicy.%s '%s': %v",
+				cfg.Name, field, val, err)
+		}
+		if d < taskMinDuration {
+			return 0, fmt.Errorf("job[%s].restartPolicy.%s '%s' cannot be less than %v",
+				cfg.Name, field, val, taskMinDuration)
+		}
+		return d, nil
+	}
+	var err error
+	if cfg.restartDelay, err = parse("delay", policy.Delay,
+		defaultRestartDelay); err != nil {
+		return err
+	}
+	if cfg.restartMaxDelay, err = parse("maxDelay", policy.MaxDelay,
+		defaultRestartMaxDelay); err != nil {
+		return err
+	}
+	if cfg.restartResetAfter, err = parse("resetAfter", policy.ResetAfter,
+		cfg.restartMaxDelay); err != nil {
+		return err
+	}
+	if cfg.restartMaxDelay < cfg.restartDelay {
+		return fmt.Errorf("job[%s].restartPolicy.maxDelay '%v' cannot be less than delay '%v'",
+			cfg.Name, cfg.restartMaxDelay, cfg.restartDelay)
+	}
+
+	switch {
+	case policy.Multiplier == 0:
+		cfg.restartMultiplier = defaultRestartMultiplier
+	case policy.Multiplier < 1:
+		return fmt.Errorf("job[%s].restartPolicy.multiplier must be >= 1",
+			cfg.Name)
+	default:
+		cfg.restartMultiplier = policy.Multiplier
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return fmt.Errorf("job[%s].restartPolicy.jitter must be between 0 and 1",
+			cfg.Name)
+	}
+	cfg.restartJitter = policy.Jitter
+	return nil
+}
+
 // addDiscoveryConfig validates the configuration for service discovery
 // and attaches the discovery.ServiceDefinition to the Config
 func (cfg *Config) addDiscoveryConfig(disc discovery.Backend) error {
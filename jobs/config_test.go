@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/joyent/containerpilot/checks"
 	"github.com/joyent/containerpilot/events"
 	"github.com/joyent/containerpilot/tests"
 	"github.com/joyent/containerpilot/tests/mocks"
@@ -67,6 +68,43 @@ func TestJobConfigHealthTimeout(t *testing.T) {
 	assert.Equal(job1.healthCheckExec.Timeout, job1.heartbeatInterval, "config for job1.Health")
 }
 
+func TestJobConfigHealthNative(t *testing.T) {
+	jobs := loadTestConfig(t)
+	assert := assert.New(t)
+
+	job0 := jobs[0]
+	assert.Equal(job0.Name, "serviceA", "config for job0.Name")
+	assert.Nil(job0.healthCheckExec, "config for job0.healthCheckExec")
+	check0, ok := job0.healthCheck.(*checks.HTTPCheck)
+	if !ok {
+		t.Fatalf("expected HTTPCheck for job0 but got %T", job0.healthCheck)
+	}
+	assert.Equal(check0.Name, "check.serviceA", "config for job0.healthCheck.Name")
+	assert.Equal(check0.URL, "http://localhost:80/health", "config for job0.healthCheck.URL")
+	assert.Equal(check0.Timeout, time.Duration(5)*time.Second, "config for job0.healthCheck.Timeout")
+
+	job1 := jobs[1]
+	assert.Equal(job1.Name, "serviceB", "config for job1.Name")
+	check1, ok := job1.healthCheck.(*checks.TCPCheck)
+	if !ok {
+		t.Fatalf("expected TCPCheck for job1 but got %T", job1.healthCheck)
+	}
+	assert.Equal(check1.Name, "check.serviceB", "config for job1.healthCheck.Name")
+	assert.Equal(check1.Address, "localhost:5432", "config for job1.healthCheck.Address")
+	assert.Equal(check1.Timeout, job1.heartbeatInterval, "config for job1.healthCheck.Timeout")
+
+	job2 := jobs[2]
+	assert.Equal(job2.Name, "serviceC", "config for job2.Name")
+	check2, ok := job2.healthCheck.(*checks.GRPCCheck)
+	if !ok {
+		t.Fatalf("expected GRPCCheck for job2 but got %T", job2.healthCheck)
+	}
+	assert.Equal(check2.Name, "check.serviceC", "config for job2.healthCheck.Name")
+	assert.Equal(check2.Address, "localhost:9000", "config for job2.healthCheck.Address")
+	assert.Equal(check2.Service, "myservice", "config for job2.healthCheck.Service")
+	assert.Equal(check2.Timeout, time.Duration(2)*time.Second, "config for job2.healthCheck.Timeout")
+}
+
 func TestJobConfigServiceWithArrayExec(t *testing.T) {
 	jobs := loadTestConfig(t)
 	assert := assert.New(t)
@@ -442,6 +480,234 @@ func TestJobConfigValidateRestarts(t *testing.T) {
 	assert.Equal(cfg[6].restartLimit, 0, expectMsg)
 }
 
+func TestJobConfigSplayJitter(t *testing.T) {
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Frequency: "10s", Splay: "1m", Jitter: "2s"},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.Equal(t, time.Minute, cfg.freqSplay)
+	assert.Equal(t, 2*time.Second, cfg.freqJitter)
+
+	expectErr := func(when *WhenConfig, errMsg string) {
+		cfg := &Config{Name: "myjob", Exec: "true", When: when}
+		err := cfg.Validate(noop)
+		if assert.Error(t, err) {
+			assert.Equal(t, errMsg, err.Error())
+		}
+	}
+	expectErr(&WhenConfig{Frequency: "10s", Jitter: "10s"},
+		"job[myjob].when.jitter '10s' must be less than the interval '10s'")
+	expectErr(&WhenConfig{Source: "upstream", Each: "changed", Splay: "10s"},
+		"job[myjob].when.splay and job[myjob].when.jitter require 'interval'")
+}
+
+func TestJobConfigConcurrency(t *testing.T) {
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Frequency: "10s"},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.Equal(t, concurrencyQueue, cfg.concurrency)
+
+	cfg = &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Cron: "@hourly", Concurrency: "replace"},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.Equal(t, concurrencyReplace, cfg.concurrency)
+
+	expectErr := func(when *WhenConfig, errMsg string) {
+		cfg := &Config{Name: "myjob", Exec: "true", When: when}
+		err := cfg.Validate(noop)
+		if assert.Error(t, err) {
+			assert.Equal(t, errMsg, err.Error())
+		}
+	}
+	expectErr(&WhenConfig{Frequency: "10s", Concurrency: "parallel"},
+		"job[myjob].when.concurrency must be one of 'skip', 'queue', or 'replace'")
+	expectErr(&WhenConfig{Source: "upstream", Once: "healthy", Concurrency: "skip"},
+		"job[myjob].when.concurrency requires 'interval' or 'cron'")
+}
+
+func TestJobConfigWhenConditions(t *testing.T) {
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{
+			Any: []ConditionConfig{
+				{Source: "db", Event: "healthy"},
+				{Source: "migrations", Event: "exitSuccess"},
+			},
+			Timeout: "10s",
+		},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.False(t, cfg.whenAll)
+	assert.Equal(t, events.NonEvent, cfg.whenEvent)
+	assert.Equal(t, 10*time.Second, cfg.whenTimeout)
+	assert.Equal(t, []events.Event{
+		{Code: events.StatusHealthy, Source: "db"},
+		{Code: events.ExitSuccess, Source: "migrations"},
+	}, cfg.whenConditions)
+
+	expectErr := func(when *WhenConfig, errMsg string) {
+		cfg := &Config{Name: "myjob", Exec: "true", When: when}
+		err := cfg.Validate(noop)
+		if assert.Error(t, err) {
+			assert.Equal(t, errMsg, err.Error())
+		}
+	}
+	db := []ConditionConfig{{Source: "db", Event: "healthy"}}
+	expectErr(&WhenConfig{All: db, Any: db},
+		"job[myjob].when can have only one of 'interval', 'cron', 'once', 'each', 'all', or 'any'")
+	expectErr(&WhenConfig{All: db, Source: "db"},
+		"job[myjob].when.source cannot be used with 'all' or 'any'")
+	expectErr(&WhenConfig{All: []ConditionConfig{{Event: "healthy"}}},
+		"job[myjob].when.all[0].source must not be blank")
+	expectErr(&WhenConfig{Any: []ConditionConfig{{Source: "db", Event: "bogus"}}},
+		"unable to parse job[myjob].when.any[0].event: bogus is not a valid event code")
+	expectErr(&WhenConfig{All: []ConditionConfig{{Source: "db-[", Event: "healthy"}}},
+		"unable to parse job[myjob].when.all[0].source 'db-[': syntax error in pattern")
+	expectErr(&WhenConfig{Source: "watch.[", Each: "changed"},
+		"unable to parse job[myjob].when.source 'watch.[': syntax error in pattern")
+}
+
+func TestJobConfigDebounceThrottle(t *testing.T) {
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{
+			Source:   "watch.app",
+			Each:     "changed",
+			Debounce: "2s",
+			Throttle: "1m",
+		},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.Equal(t, 2*time.Second, cfg.whenDebounce)
+	assert.Equal(t, time.Minute, cfg.whenThrottle)
+
+	cfg = &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Source: "SIGHUP", Throttle: "1m"},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.Equal(t, time.Minute, cfg.whenThrottle)
+
+	expectErr := func(when *WhenConfig, errMsg string) {
+		cfg := &Config{Name: "myjob", Exec: "true", When: when}
+		err := cfg.Validate(noop)
+		if assert.Error(t, err) {
+			assert.Equal(t, errMsg, err.Error())
+		}
+	}
+	expectErr(&WhenConfig{Source: "watch.app", Once: "changed", Debounce: "2s"},
+		"job[myjob].when.debounce and job[myjob].when.throttle require 'each'")
+	expectErr(&WhenConfig{Source: "watch.app", Each: "changed", Throttle: "-1s"},
+		"job[myjob].when.throttle '-1s' cannot be negative")
+}
+
+func TestJobConfigDelay(t *testing.T) {
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Source: "primary", Once: "healthy", Delay: "30s"},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.Equal(t, 30*time.Second, cfg.whenDelay)
+
+	cfg = &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Frequency: "10s", Delay: "30s"},
+	}
+	err := cfg.Validate(noop)
+	if assert.Error(t, err) {
+		assert.Equal(t,
+			"job[myjob].when.delay cannot be used with 'interval' or 'cron'",
+			err.Error())
+	}
+}
+
+func TestJobConfigCron(t *testing.T) {
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Cron: "30 2 * * *", Timezone: "UTC"},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.NotNil(t, cfg.schedule)
+	assert.Equal(t, events.NonEvent, cfg.whenEvent)
+	assert.Equal(t, unlimited, cfg.restartLimit)
+
+	expectErr := func(when *WhenConfig, errMsg string) {
+		cfg := &Config{Name: "myjob", Exec: "true", When: when}
+		err := cfg.Validate(noop)
+		if assert.Error(t, err) {
+			assert.Equal(t, errMsg, err.Error())
+		}
+	}
+	expectErr(&WhenConfig{Cron: "* * *"},
+		"unable to parse job[myjob].when.cron '* * *': Expected exactly 5 fields, found 3: * * *")
+	expectErr(&WhenConfig{Cron: "@daily", Frequency: "1s"},
+		"job[myjob].when can have only one of 'interval', 'cron', 'once', 'each', 'all', or 'any'")
+	expectErr(&WhenConfig{Timezone: "UTC", Source: "upstream", Each: "changed"},
+		"job[myjob].when.timezone requires 'cron'")
+}
+
+func TestJobConfigRestartPolicy(t *testing.T) {
+	cfg := &Config{
+		Name:          "myjob",
+		Exec:          "true",
+		RestartPolicy: &RestartPolicyConfig{Delay: "2s", Jitter: 0.1},
+	}
+	assert.NoError(t, cfg.Validate(noop))
+	assert.Equal(t, 2*time.Second, cfg.restartDelay)
+	assert.Equal(t, 2.0, cfg.restartMultiplier)
+	assert.Equal(t, time.Minute, cfg.restartMaxDelay)
+	assert.Equal(t, time.Minute, cfg.restartResetAfter)
+	assert.Equal(t, 0.1, cfg.restartJitter)
+
+	expectErr := func(policy *RestartPolicyConfig, errMsg string) {
+		cfg := &Config{Name: "myjob", Exec: "true", RestartPolicy: policy}
+		err := cfg.Validate(noop)
+		if assert.Error(t, err) {
+			assert.Equal(t, errMsg, err.Error())
+		}
+	}
+	expectErr(&RestartPolicyConfig{Delay: "0"},
+		"job[myjob].restartPolicy.delay '0' cannot be less than 1ms")
+	expectErr(&RestartPolicyConfig{Delay: "2m"},
+		"job[myjob].restartPolicy.maxDelay '1m0s' cannot be less than delay '2m0s'")
+	expectErr(&RestartPolicyConfig{Multiplier: 0.5},
+		"job[myjob].restartPolicy.multiplier must be >= 1")
+	expectErr(&RestartPolicyConfig{Jitter: 2},
+		"job[myjob].restartPolicy.jitter must be between 0 and 1")
+}
+
+func TestJobConfigWarningExitCodes(t *testing.T) {
+	warningCodes := func(raw string) []int {
+		jobs, err := NewConfigs(tests.DecodeRawToSlice(raw), noop)
+		if err != nil {
+			t.Fatalf("unexpected error in NewConfigs: %v", err)
+		}
+		return jobs[0].warningExitCodes
+	}
+	assert.Equal(t, []int{1}, warningCodes(
+		`[{name: "myName", port: 80, health: {exec: "/bin/true", interval: 1, ttl: 5}}]`))
+	assert.Equal(t, []int{3, 4}, warningCodes(
+		`[{name: "myName", port: 80, health: {exec: "/bin/true", interval: 1, ttl: 5, warningExitCodes: [3, 4]}}]`))
+	assert.Empty(t, warningCodes(
+		`[{name: "myName", port: 80, health: {exec: "/bin/true", interval: 1, ttl: 5, warningExitCodes: []}}]`))
+	assert.Empty(t, warningCodes(
+		`[{name: "myName", port: 80, health: {tcp: {address: "localhost:80"}, interval: 1, ttl: 5}}]`))
+}
+
 func TestHealthChecksConfigError(t *testing.T) {
 
 	expectErr := func(test, errMsg string) {
@@ -461,6 +727,39 @@ func TestHealthChecksConfigError(t *testing.T) {
 	expectErr(
 		`[{name: "myName", health: {exec: "/bin/true", interval: 1, ttl: 5, timeout: "xx"}}]`,
 		"could not parse job[myName].health.timeout 'xx': time: invalid duration xx")
+	expectErr(
+		`[{name: "myName", health: {exec: "/bin/true", tcp: {address: "localhost:80"}, interval: 1, ttl: 5}}]`,
+		"job[myName].health can have only one of 'exec', 'http', 'tcp', or 'grpc'")
+	expectErr(
+		`[{name: "myName", health: {http: {url: ""}, interval: 1, ttl: 5}}]`,
+		"unable to create job[myName].health.http: 'url' must not be blank")
+	expectErr(
+		`[{name: "myName", health: {tcp: {address: "localhost"}, interval: 1, ttl: 5}}]`,
+		"unable to create job[myName].health.tcp: could not parse 'address': address localhost: missing port in address")
+	expectErr(
+		`[{name: "myName", health: {grpc: {address: ""}, interval: 1, ttl: 5}}]`,
+		"unable to create job[myName].health.grpc: 'address' must not be blank")
+	expectErr(
+		`[{name: "myName", health: {exec: "/bin/true", interval: 1, ttl: 5, rise: -1}}]`,
+		"job[myName].health.rise must be >= 0")
+	expectErr(
+		`[{name: "myName", health: {exec: "/bin/true", interval: 1, ttl: 5, fall: -1}}]`,
+		"job[myName].health.fall must be >= 0")
+	expectErr(
+		`[{name: "myName", health: {exec: "/bin/true", interval: 1, ttl: 5, flap: {transitions: 3}}}]`,
+		"job[myName].health.flap.window must be set")
+	expectErr(
+		`[{name: "myName", health: {exec: "/bin/true", interval: 1, ttl: 5, flap: {window: "1m", transitions: 1}}}]`,
+		"job[myName].health.flap.transitions must be >= 2")
+	expectErr(
+		`[{name: "myName", health: {exec: "/bin/true", interval: 1, ttl: 5, startPeriod: "xx"}}]`,
+		"could not parse job[myName].health.startPeriod 'xx': time: invalid duration xx")
+	expectErr(
+		`[{name: "myName", health: {tcp: {address: "localhost:80"}, interval: 1, ttl: 5, warningExitCodes: [1]}}]`,
+		"job[myName].health.warningExitCodes requires 'exec'")
+	expectErr(
+		`[{name: "myName", health: {exec: "/bin/true", interval: 1, ttl: 5, warningExitCodes: [0]}}]`,
+		"job[myName].health.warningExitCodes '0' must be between 1 and 255")
 }
 
 // ---------------------------------------------------------------------
@@ -0,0 +1,305 @@
+package jobs
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+// trigger is an event that a Job waits on before it starts, along with
+// the name of the config field it came from for error messages
+type trigger struct {
+	field string
+	event events.Event
+}
+
+// triggers returns the events that the Job's 'when' field waits on
+func (cfg *Config) triggers() []trigger {
+	if len(cfg.whenConditions) > 0 {
+		field := "any"
+		if cfg.whenAll {
+			field = "all"
+		}
+		triggers := []trigger{}
+		for i, event := range cfg.whenConditions {
+			triggers = append(triggers, trigger{
+				field: fmt.Sprintf("when.%s[%d].source", field, i),
+				event: event,
+			})
+		}
+		return triggers
+	}
+	if cfg.whenEvent == events.NonEvent || cfg.whenEvent == events.GlobalStartup {
+		return nil
+	}
+	return []trigger{{field: "when.source", event: cfg.whenEvent}}
+}
+
+// blocksOn reports whether a Job with this trigger can't start until the
+// source has published its event. A Job waiting on any of several sources
+// or on a pattern isn't blocked by any one source, and a Job waiting on
+// another Job to stop orders the shutdown rather than the startup.
+func (cfg *Config) blocksOn(t trigger) bool {
+	if len(cfg.whenConditions) > 1 && !cfg.whenAll {
+		return false
+	}
+	if t.event.Code == events.Stopping || t.event.Code == events.Stopped {
+		return false
+	}
+	return !isPattern(t.event.Source)
+}
+
+// ValidateGraph checks the triggers of all the Jobs against each other
+// and against the names of the watches. It returns an error if a Job
+// waits on a source that no job or watch publishes, or if Jobs wait on
+// each other in a cycle so that none of them can ever start.
+func ValidateGraph(cfgs []*Config, watchNames []string) error {
+	sources := []string{"global", "SIGHUP", "SIGUSR2"}
+	for _, cfg := range cfgs {
+		sources = append(sources, cfg.Name)
+		if cfg.healthCheck != nil {
+			sources = append(sources, "check."+cfg.Name)
+		}
+	}
+	sources = append(sources, watchNames...)
+
+	for _, cfg := range cfgs {
+		for _, t := range cfg.triggers() {
+			if t.event.Code == events.Metric || t.event.Code == events.Error {
+				continue // these sources are arbitrary strings
+			}
+			if !matchesAny(t.event.Source, sources) {
+				return fmt.Errorf("job[%s].%s '%s' does not match any job or watch",
+					cfg.Name, t.field, t.event.Source)
+			}
+		}
+	}
+	return findCycle(cfgs)
+}
+
+// ValidateAdded checks the triggers of a Job that's added alongside the
+// running Jobs, as ValidateGraph does for the Jobs of the configuration
+func ValidateAdded(cfg *Config, running []*Job, watchNames []string) error {
+	cfgs := []*Config{cfg}
+	for _, job := range running {
+		cfgs = append(cfgs, job.cfg)
+	}
+	return ValidateGraph(cfgs, watchNames)
+}
+
+// findCycle does a depth-first search of the Jobs that block on other
+// Jobs and returns an error naming the first cycle it finds
+func findCycle(cfgs []*Config) error {
+	byName := map[string]*Config{}
+	names := []string{}
+	for _, cfg := range cfgs {
+		byName[cfg.Name] = cfg
+		names = append(names, cfg.Name)
+	}
+	sort.Strings(names) // so that the error is deterministic
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	stack := []string{}
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+		cfg := byName[name]
+		for _, t := range cfg.triggers() {
+			if _, isJob := byName[t.event.Source]; !isJob || !cfg.blocksOn(t) {
+				continue
+			}
+			switch state[t.event.Source] {
+			case visiting:
+				for i, seen := range stack {
+					if seen == t.event.Source {
+						return append(stack[i:], t.event.Source)
+					}
+				}
+			case unvisited:
+				if cycle := visit(t.event.Source); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, name := range names {
+		if state[name] != unvisited {
+			continue
+		}
+		if cycle := visit(name); cycle != nil {
+			return fmt.Errorf("job[%s].when has a dependency cycle: %s",
+				cycle[0], strings.Join(cycle, " -> "))
+		}
+	}
+	return nil
+}
+
+// Graph is the trigger graph of a set of Jobs: the jobs, watches, and
+// signals that each Job waits on before it starts or stops
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a job, watch, or other source of events in a Graph
+type GraphNode struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // job, watch, signal, global, check, or other
+}
+
+// GraphEdge is a Job waiting on an event from a source. The Event is
+// named as it's written in the configuration (ex. "healthy"), or is the
+// name of the signal. The Kind is "start" if the Job starts on the event,
+// or "stop" if the Job waits for the event before it stops.
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Event string `json:"event"`
+	Kind  string `json:"kind"`
+}
+
+// graphEmits are the event codes that each kind of node in a Graph can
+// publish
+var graphEmits = map[string][]events.EventCode{
+	"job": {events.ExitSuccess, events.ExitFailed, events.Stopping,
+		events.Stopped, events.StatusHealthy, events.StatusUnhealthy,
+		events.TimerExpired, events.EnterMaintenance, events.ExitMaintenance,
+		events.Skipped, events.Replaced},
+	"watch":  {events.StatusChanged, events.StatusHealthy, events.StatusUnhealthy},
+	"check":  {events.ExitSuccess, events.ExitFailed},
+	"signal": {events.Signal},
+	"global": {events.Startup, events.Shutdown, events.EnterMaintenance,
+		events.ExitMaintenance},
+}
+
+func canEmit(kind string, code events.EventCode) bool {
+	for _, emitted := range graphEmits[kind] {
+		if emitted == code {
+			return true
+		}
+	}
+	return false
+}
+
+// NewGraph builds the trigger graph for validated Jobs and the names of
+// the watches. A trigger with a pattern source has an edge from each of
+// the jobs and watches that it matches and that can publish its event.
+func NewGraph(cfgs []*Config, watchNames []string) *Graph {
+	kinds := map[string]string{"global": "global", "SIGHUP": "signal", "SIGUSR2": "signal"}
+	for _, cfg := range cfgs {
+		kinds[cfg.Name] = "job"
+		if cfg.healthCheck != nil {
+			kinds["check."+cfg.Name] = "check"
+		}
+	}
+	for _, name := range watchNames {
+		kinds[name] = "watch"
+	}
+
+	graph := &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	used := map[string]string{}
+	addEdge := func(from, to string, code events.EventCode, kind string) {
+		used[from] = kinds[from]
+		event := code.ConfigName()
+		if code == events.Signal {
+			event = from
+		}
+		graph.Edges = append(graph.Edges, GraphEdge{
+			From: from, To: to, Event: event, Kind: kind})
+	}
+	for _, cfg := range cfgs {
+		for _, t := range cfg.triggers() {
+			if !isPattern(t.event.Source) {
+				addEdge(t.event.Source, cfg.Name, t.event.Code, "start")
+				continue
+			}
+			for _, source := range sortedKeys(kinds) {
+				if sourceMatches(t.event.Source, source) &&
+					canEmit(kinds[source], t.event.Code) {
+					addEdge(source, cfg.Name, t.event.Code, "start")
+				}
+			}
+		}
+		if cfg.stoppingWaitEvent != events.NonEvent {
+			addEdge(cfg.stoppingWaitEvent.Source, cfg.Name,
+				cfg.stoppingWaitEvent.Code, "stop")
+		}
+	}
+	for _, cfg := range cfgs {
+		used[cfg.Name] = "job"
+	}
+	for _, name := range watchNames {
+		used[name] = "watch"
+	}
+	for _, name := range sortedKeys(used) {
+		kind := used[name]
+		if kind == "" {
+			kind = "other"
+		}
+		graph.Nodes = append(graph.Nodes, GraphNode{Name: name, Kind: kind})
+	}
+	return graph
+}
+
+// DOT formats the Graph in the Graphviz DOT language
+func (graph *Graph) DOT() string {
+	shapes := map[string]string{
+		"job":    "box",
+		"watch":  "ellipse",
+		"signal": "diamond",
+		"global": "doublecircle",
+	}
+	var b bytes.Buffer
+	b.WriteString("digraph containerpilot {\n")
+	for _, node := range graph.Nodes {
+		shape, ok := shapes[node.Kind]
+		if !ok {
+			shape = "plaintext"
+		}
+		fmt.Fprintf(&b, "  %q [shape=%s];\n", node.Name, shape)
+	}
+	for _, edge := range graph.Edges {
+		style := "solid"
+		if edge.Kind == "stop" {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, style=%s];\n",
+			edge.From, edge.To, edge.Event, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isPattern(source string) bool {
+	return strings.ContainsAny(source, `*?[\`)
+}
+
+func matchesAny(pattern string, sources []string) bool {
+	for _, source := range sources {
+		if pattern == source || sourceMatches(pattern, source) {
+			return true
+		}
+	}
+	return false
+}
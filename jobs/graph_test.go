@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateGraph(t *testing.T) {
+	newConfigs := func(t *testing.T, raw []interface{}) []*Config {
+		cfgs, err := NewConfigs(raw, noop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfgs
+	}
+	job := func(name string, when map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"name": name, "exec": "true", "when": when}
+	}
+	watches := []string{"watch.app"}
+
+	t.Run("valid", func(t *testing.T) {
+		cfgs := newConfigs(t, []interface{}{
+			job("db", nil),
+			job("migrations", map[string]interface{}{"source": "db", "once": "healthy"}),
+			job("app", map[string]interface{}{"all": []interface{}{
+				map[string]interface{}{"source": "migrations", "event": "exitSuccess"},
+				map[string]interface{}{"source": "watch.app", "event": "changed"},
+			}}),
+			job("reload", map[string]interface{}{"source": "watch.*", "each": "changed"}),
+			job("hup", map[string]interface{}{"source": "SIGHUP"}),
+		})
+		assert.NoError(t, ValidateGraph(cfgs, watches))
+	})
+	t.Run("unknown source", func(t *testing.T) {
+		cfgs := newConfigs(t, []interface{}{
+			job("app", map[string]interface{}{"source": "dbb", "once": "healthy"}),
+		})
+		err := ValidateGraph(cfgs, watches)
+		if assert.Error(t, err) {
+			assert.Equal(t,
+				"job[app].when.source 'dbb' does not match any job or watch",
+				err.Error())
+		}
+	})
+	t.Run("unmatched pattern", func(t *testing.T) {
+		cfgs := newConfigs(t, []interface{}{
+			job("app", map[string]interface{}{"any": []interface{}{
+				map[string]interface{}{"source": "watch.*", "event": "changed"},
+				map[string]interface{}{"source": "backend-*", "event": "healthy"},
+			}}),
+		})
+		err := ValidateGraph(cfgs, watches)
+		if assert.Error(t, err) {
+			assert.Equal(t,
+				"job[app].when.any[1].source 'backend-*' does not match any job or watch",
+				err.Error())
+		}
+	})
+	t.Run("cycle", func(t *testing.T) {
+		cfgs := newConfigs(t, []interface{}{
+			job("c", map[string]interface{}{"source": "a", "once": "healthy"}),
+			job("a", map[string]interface{}{"source": "b", "once": "healthy"}),
+			job("b", map[string]interface{}{"all": []interface{}{
+				map[string]interface{}{"source": "watch.app", "event": "changed"},
+				map[string]interface{}{"source": "a", "event": "exitSuccess"},
+			}}),
+		})
+		err := ValidateGraph(cfgs, watches)
+		if assert.Error(t, err) {
+			assert.Equal(t,
+				"job[a].when has a dependency cycle: a -> b -> a",
+				err.Error())
+		}
+	})
+	t.Run("self", func(t *testing.T) {
+		cfgs := newConfigs(t, []interface{}{
+			job("a", map[string]interface{}{"source": "a", "each": "exitFailed"}),
+		})
+		err := ValidateGraph(cfgs, watches)
+		if assert.Error(t, err) {
+			assert.Equal(t,
+				"job[a].when has a dependency cycle: a -> a", err.Error())
+		}
+	})
+	t.Run("not blocked by any or patterns", func(t *testing.T) {
+		cfgs := newConfigs(t, []interface{}{
+			job("alert", map[string]interface{}{"source": "*", "each": "exitFailed"}),
+			job("a", map[string]interface{}{"any": []interface{}{
+				map[string]interface{}{"source": "b", "event": "healthy"},
+				map[string]interface{}{"source": "watch.app", "event": "changed"},
+			}}),
+			job("b", map[string]interface{}{"source": "a", "once": "healthy"}),
+		})
+		assert.NoError(t, ValidateGraph(cfgs, watches))
+	})
+	t.Run("not blocked by shutdown ordering", func(t *testing.T) {
+		cfgs := newConfigs(t, []interface{}{
+			job("app", map[string]interface{}{"source": "db", "once": "healthy"}),
+			job("db", map[string]interface{}{"source": "app", "once": "stopped"}),
+			job("cleanup", map[string]interface{}{"source": "cleanup", "once": "stopping"}),
+		})
+		assert.NoError(t, ValidateGraph(cfgs, watches))
+	})
+}
+
+func TestNewGraph(t *testing.T) {
+	cfgs, err := NewConfigs([]interface{}{
+		map[string]interface{}{"name": "consul-agent", "exec": "consul", "stopTimeout": "5s"},
+		map[string]interface{}{"name": "leave", "exec": "true",
+			"when": map[string]interface{}{"source": "consul-agent", "once": "stopping"}},
+		map[string]interface{}{"name": "reload", "exec": "true",
+			"when": map[string]interface{}{"source": "watch.*", "each": "changed"}},
+		map[string]interface{}{"name": "hup", "exec": "true",
+			"when": map[string]interface{}{"source": "SIGHUP"}},
+	}, noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := NewGraph(cfgs, []string{"watch.a", "watch.b"})
+	assert.Equal(t, []GraphNode{
+		{Name: "SIGHUP", Kind: "signal"},
+		{Name: "consul-agent", Kind: "job"},
+		{Name: "hup", Kind: "job"},
+		{Name: "leave", Kind: "job"},
+		{Name: "reload", Kind: "job"},
+		{Name: "watch.a", Kind: "watch"},
+		{Name: "watch.b", Kind: "watch"},
+	}, graph.Nodes)
+	assert.Equal(t, []GraphEdge{
+		{From: "leave", To: "consul-agent", Event: "stopped", Kind: "stop"},
+		{From: "consul-agent", To: "leave", Event: "stopping", Kind: "start"},
+		{From: "watch.a", To: "reload", Event: "changed", Kind: "start"},
+		{From: "watch.b", To: "reload", Event: "changed", Kind: "start"},
+		{From: "SIGHUP", To: "hup", Event: "SIGHUP", Kind: "start"},
+	}, graph.Edges)
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, `"watch.a" [shape=ellipse];`)
+	assert.Contains(t, dot, `"leave" -> "consul-agent" [label="stopped", style=dashed];`)
+	assert.Contains(t, dot, `"SIGHUP" -> "hup" [label="SIGHUP", style=solid];`)
+}
+
+func TestNewGraphPatternSources(t *testing.T) {
+	cfgs, err := NewConfigs([]interface{}{
+		map[string]interface{}{"name": "db", "exec": "true",
+			"health": map[string]interface{}{"exec": "false", "interval": 1, "ttl": 5}},
+		map[string]interface{}{"name": "alert", "exec": "true",
+			"when": map[string]interface{}{"source": "*", "each": "exitFailed"}},
+	}, noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := NewGraph(cfgs, []string{"watch.a"})
+	// not the health check, signals, or global, and not the watch
+	// because watches don't exit
+	assert.Equal(t, []GraphEdge{
+		{From: "alert", To: "alert", Event: "exitFailed", Kind: "start"},
+		{From: "db", To: "alert", Event: "exitFailed", Kind: "start"},
+	}, graph.Edges)
+}
@@ -0,0 +1,149 @@
+package jobs
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// checkResult is the outcome of a single run of a health check
+type checkResult int
+
+const (
+	checkPassed checkResult = iota
+	checkWarning
+	checkFailed
+)
+
+// healthState debounces the results of a Job's health checks. The Job
+// only changes its status after 'rise' consecutive passes or 'fall'
+// consecutive failures, and if flap detection is configured it holds its
+// last stable status while the raw check results keep flipping. Failures
+// during the start period after the Job's exec starts are ignored.
+type healthState struct {
+	name string
+	rise int
+	fall int
+
+	startPeriod     time.Duration
+	startPeriodEnds time.Time
+
+	passes    int
+	failures  int
+	hasResult bool
+	lastPass  bool
+
+	flapWindow      time.Duration
+	flapTransitions int
+	flips           []time.Time
+	flapping        bool
+}
+
+func newHealthState(cfg *Config) healthState {
+	return healthState{
+		name:            cfg.Name,
+		rise:            cfg.healthRise,
+		fall:            cfg.healthFall,
+		startPeriod:     cfg.healthStartPeriod,
+		flapWindow:      cfg.flapWindow,
+		flapTransitions: cfg.flapTransitions,
+	}
+}
+
+// record adds the result of a health check and returns the status the
+// Job should have, given its current status. Callers should only publish
+// a status event if the returned status differs from the current one.
+// A warning counts as a pass towards 'rise' but leaves the Job degraded.
+func (h *healthState) record(result checkResult, current JobStatus, now time.Time) JobStatus {
+	passed := result != checkFailed
+	if now.Before(h.startPeriodEnds) {
+		if !passed {
+			log.Debugf("job[%s] health check failed during start period",
+				h.name)
+			return current
+		}
+		h.startPeriodEnds = time.Time{} // first success ends the start period
+	}
+	if h.hasResult && h.lastPass != passed {
+		h.flips = append(h.flips, now)
+	}
+	h.hasResult = true
+	h.lastPass = passed
+
+	target := current
+	if passed {
+		h.passes++
+		h.failures = 0
+		if h.passes >= threshold(h.rise) {
+			target = statusHealthy
+			if result == checkWarning {
+				target = statusDegraded
+			}
+		}
+	} else {
+		h.failures++
+		h.passes = 0
+		if h.failures >= threshold(h.fall) {
+			target = statusUnhealthy
+		}
+	}
+	if h.isFlapping(now) && target != current {
+		log.Debugf("job[%s] health is flapping; holding status '%s'",
+			h.name, current)
+		return current
+	}
+	return target
+}
+
+// isFlapping prunes any flips older than the flap window and reports
+// whether the remaining flips exceed the configured number of transitions
+func (h *healthState) isFlapping(now time.Time) bool {
+	if h.flapTransitions < 1 {
+		return false
+	}
+	cutoff := now.Add(-h.flapWindow)
+	i := 0
+	for i < len(h.flips) && !h.flips[i].After(cutoff) {
+		i++
+	}
+	h.flips = h.flips[i:]
+
+	flapping := len(h.flips) >= h.flapTransitions
+	if flapping != h.flapping {
+		if flapping {
+			log.Warnf("job[%s] health is flapping: %d transitions in %v",
+				h.name, len(h.flips), h.flapWindow)
+		} else {
+			log.Infof("job[%s] health is no longer flapping", h.name)
+		}
+		h.flapping = flapping
+	}
+	return flapping
+}
+
+// reset clears the consecutive pass/fail counts whenever the Job's status
+// is reset to unknown. The flap history is kept so that a Job restarting
+// in a loop can't use the restart to escape flap detection.
+func (h *healthState) reset() {
+	h.passes = 0
+	h.failures = 0
+	h.hasResult = false
+}
+
+// start resets the Job's health when its exec starts and begins the
+// start period, if any
+func (h *healthState) start(now time.Time) {
+	h.reset()
+	if h.startPeriod > 0 {
+		h.startPeriodEnds = now.Add(h.startPeriod)
+	}
+}
+
+// a zero threshold is the zero-value of a Job that was created without a
+// Config and behaves as though no rise/fall were set
+func threshold(count int) int {
+	if count < 1 {
+		return 1
+	}
+	return count
+}
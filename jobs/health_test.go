@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthStateRiseFall(t *testing.T) {
+	h := &healthState{name: "myjob", rise: 2, fall: 3}
+	now := time.Now()
+	status := statusUnknown
+	record := func(result checkResult) JobStatus {
+		status = h.record(result, status, now)
+		return status
+	}
+	assert.Equal(t, statusUnknown, record(checkPassed), "1st pass is below rise")
+	assert.Equal(t, statusHealthy, record(checkPassed), "2nd pass crosses rise")
+	assert.Equal(t, statusHealthy, record(checkFailed), "1st failure is below fall")
+	assert.Equal(t, statusHealthy, record(checkFailed), "2nd failure is below fall")
+	assert.Equal(t, statusHealthy, record(checkPassed), "pass resets failures")
+	assert.Equal(t, statusHealthy, record(checkFailed))
+	assert.Equal(t, statusHealthy, record(checkFailed))
+	assert.Equal(t, statusUnhealthy, record(checkFailed), "3rd failure crosses fall")
+
+	h.reset()
+	status = statusUnknown
+	assert.Equal(t, statusUnknown, record(checkPassed), "reset clears passes")
+}
+
+func TestHealthStateFlapping(t *testing.T) {
+	h := &healthState{name: "myjob", flapWindow: 10 * time.Second, flapTransitions: 3}
+	now := time.Now()
+	status := statusUnknown
+	record := func(result checkResult) JobStatus {
+		status = h.record(result, status, now)
+		now = now.Add(time.Second)
+		return status
+	}
+	assert.Equal(t, statusHealthy, record(checkPassed))
+	assert.Equal(t, statusUnhealthy, record(checkFailed), "1st flip")
+	assert.Equal(t, statusHealthy, record(checkPassed), "2nd flip")
+	assert.Equal(t, statusHealthy, record(checkFailed), "3rd flip holds status")
+	assert.Equal(t, statusHealthy, record(checkFailed), "still flapping")
+
+	// once the flips age out of the window the status can change again
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, statusUnhealthy, record(checkFailed))
+}
+
+func TestHealthStateStartPeriod(t *testing.T) {
+	h := &healthState{name: "myjob", startPeriod: 30 * time.Second}
+	now := time.Now()
+	h.start(now)
+	status := statusUnknown
+	record := func(result checkResult) JobStatus {
+		status = h.record(result, status, now)
+		now = now.Add(time.Second)
+		return status
+	}
+	assert.Equal(t, statusUnknown, record(checkFailed), "failure in start period")
+	assert.Equal(t, statusUnknown, record(checkFailed), "failure in start period")
+	assert.Equal(t, statusHealthy, record(checkPassed))
+	assert.Equal(t, statusUnhealthy, record(checkFailed),
+		"first success should end the start period")
+
+	h.start(now)
+	status = statusUnknown
+	assert.Equal(t, statusUnknown, record(checkFailed), "restart begins start period")
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, statusUnhealthy, record(checkFailed), "start period expired")
+}
+
+func TestHealthStateWarning(t *testing.T) {
+	h := &healthState{name: "myjob", rise: 2}
+	now := time.Now()
+	status := statusUnknown
+	record := func(result checkResult) JobStatus {
+		status = h.record(result, status, now)
+		return status
+	}
+	assert.Equal(t, statusUnknown, record(checkWarning), "1st warning is below rise")
+	assert.Equal(t, statusDegraded, record(checkWarning), "warnings count towards rise")
+	assert.Equal(t, statusHealthy, record(checkPassed))
+	assert.Equal(t, statusDegraded, record(checkWarning))
+	assert.Equal(t, statusUnhealthy, record(checkFailed))
+}
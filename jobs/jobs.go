@@ -4,6 +4,7 @@ package jobs
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,22 +22,62 @@ const (
 	jobContinue     processEventStatus = false
 	jobHalt         processEventStatus = true
 	eventBufferSize                    = 1000
+
+	// triggerSourceEnv is the environment variable that passes the source
+	// of the event that triggered the Job to its exec
+	triggerSourceEnv = "CONTAINERPILOT_TRIGGER_SOURCE"
 )
 
+// healthChecker is implemented by both health check execs and the
+// native health checks. Each publishes an ExitSuccess or ExitFailed
+// event with its name as the Source when it completes.
+type healthChecker interface {
+	Run(context.Context, *events.EventBus)
+}
+
+// exitCoder is implemented by health checks that report the exit code of
+// their most recent run, so that the Job can map it to a warning status.
+type exitCoder interface {
+	ExitCode() int
+}
+
+// outputter is implemented by health checks that capture the output of
+// their most recent run, so that the Job can report it to Consul.
+type outputter interface {
+	Output() string
+}
+
 // Job manages the state of a job and its start/stop conditions
 type Job struct {
-	Name string
-	exec *commands.Command
+	Name  string
+	exec  *commands.Command
+	cfg   *Config // for validating jobs added alongside this one
+	clock events.Clock
 
 	// service health and discovery
-	Status          JobStatus
-	statusLock      *sync.RWMutex
-	Service         *discovery.ServiceDefinition
-	healthCheckExec *commands.Command
-	healthCheckName string
+	Status           JobStatus
+	statusLock       *sync.RWMutex
+	Service          *discovery.ServiceDefinition
+	healthCheck      healthChecker
+	healthCheckName  string
+	health           healthState
+	warningExitCodes []int
+	checkOutput      string
+
+	// maintenance mode
+	maintenance        maintenance
+	maintenanceRequest maintenance
+	maintenanceLock    *sync.RWMutex
 
 	// starting events
 	startEvent        events.Event
+	startConditions   *whenConditions
+	startSource       string
+	startLimiter      *triggerLimiter
+	startDelay        time.Duration
+	delayedStarts     int
+	delayCtx          context.Context
+	cancelDelay       context.CancelFunc
 	startTimeout      time.Duration
 	startsRemain      int
 	startTimeoutEvent events.Event
@@ -49,7 +90,23 @@ type Job struct {
 	heartbeat      time.Duration
 	restartLimit   int
 	restartsRemain int
+	backoff        *restartBackoff
+	backoffCtx     context.Context
+	cancelBackoff  context.CancelFunc
 	frequency      time.Duration
+	splay          time.Duration
+	jitter         time.Duration
+	schedule       *cronSchedule
+
+	// overlapping runs of periodic jobs
+	concurrency concurrencyPolicy
+	running     bool
+	runQueued   bool
+	runSkipped  bool // a run came due while another was already queued
+
+	// stopped or restarted through the control plane
+	stopped          bool
+	restartRequested bool
 
 	// completed
 	IsComplete   bool
@@ -61,24 +118,55 @@ type Job struct {
 
 // NewJob creates a new Job from a Config
 func NewJob(cfg *Config) *Job {
+	return NewSimulatedJob(cfg, events.WallClock{}, nil)
+}
+
+// NewSimulatedJob creates a new Job from a Config whose timers run on the
+// clock. If stubs isn't nil, the Job's exec and health check run the
+// stubs in place of real processes or checks.
+func NewSimulatedJob(cfg *Config, clock events.Clock, stubs commands.Stubs) *Job {
 	job := &Job{
 		Name:              cfg.Name,
 		exec:              cfg.exec,
+		cfg:               cfg,
+		clock:             clock,
 		heartbeat:         cfg.heartbeatInterval,
 		Service:           cfg.serviceDefinition,
-		healthCheckExec:   cfg.healthCheckExec,
+		healthCheck:       cfg.healthCheck,
+		healthCheckName:   "check." + cfg.Name,
+		health:            newHealthState(cfg),
+		warningExitCodes:  cfg.warningExitCodes,
 		startEvent:        cfg.whenEvent,
+		startConditions:   newWhenConditions(cfg),
+		startLimiter:      newTriggerLimiter(cfg),
+		startDelay:        cfg.whenDelay,
 		startTimeout:      cfg.whenTimeout,
 		startsRemain:      cfg.whenStartsLimit,
 		stoppingWaitEvent: cfg.stoppingWaitEvent,
 		stoppingTimeout:   cfg.stoppingTimeout,
 		restartLimit:      cfg.restartLimit,
 		restartsRemain:    cfg.restartLimit,
+		backoff:           newRestartBackoff(cfg),
 		frequency:         cfg.freqInterval,
+		splay:             cfg.freqSplay,
+		jitter:            cfg.freqJitter,
+		schedule:          cfg.schedule,
+		concurrency:       cfg.concurrency,
 	}
 	job.statusLock = &sync.RWMutex{}
 	job.completeLock = &sync.RWMutex{}
+	job.maintenanceLock = &sync.RWMutex{}
 	job.Rx = make(chan events.Event, eventBufferSize)
+	if stubs != nil {
+		if job.exec != nil {
+			job.exec = commands.NewStub(job.exec.Name, job.exec.Timeout,
+				clock, stubs)
+		}
+		if job.healthCheck != nil {
+			job.healthCheck = commands.NewStub(job.healthCheckName,
+				cfg.healthCheckTimeout, clock, stubs)
+		}
+	}
 	if job.Name == "containerpilot" {
 		// right now this hardcodes the telemetry service to
 		// be always "healthy", but maybe we want to have it verify itself
@@ -98,10 +186,30 @@ func FromConfigs(cfgs []*Config) []*Job {
 	return jobs
 }
 
-// SendHeartbeat sends a heartbeat for this Job's service
+// SendHeartbeat sends a heartbeat for this Job's service, along with the
+// output of the most recent health check. A degraded Job's heartbeat
+// marks its service as warning rather than passing, and an unhealthy
+// Job's marks it as critical.
 func (job *Job) SendHeartbeat() {
-	if job.Service != nil {
+	if job.Service == nil {
+		return
+	}
+	status := job.GetStatus()
+	output := job.checkOutput
+	if output == "" && status != statusUnhealthy && status != statusDegraded {
 		job.Service.SendHeartbeat()
+		return
+	}
+	if output == "" {
+		output = status.String()
+	}
+	switch status {
+	case statusDegraded:
+		job.Service.SendWarning(output)
+	case statusUnhealthy:
+		job.Service.SendFailure(output)
+	default:
+		job.Service.SendPassing(output)
 	}
 }
 
@@ -133,6 +241,19 @@ func (job *Job) setComplete() {
 	job.IsComplete = true
 }
 
+// NextRun returns the next time a Job with a cron schedule will run, or
+// the zero time if the Job doesn't have a cron schedule
+func (job *Job) NextRun() time.Time {
+	if job.schedule == nil {
+		return time.Time{}
+	}
+	return job.schedule.Next(job.clock.Now())
+}
+
+func (job *Job) isPeriodic() bool {
+	return job.frequency > 0 || job.schedule != nil
+}
+
 // Kill sends SIGTERM to the Job's executable, if any
 func (job *Job) Kill() {
 	if job.exec != nil {
@@ -145,24 +266,29 @@ func (job *Job) Run(pctx context.Context, completedCh chan struct{}) {
 	ctx, cancel := context.WithCancel(pctx)
 
 	if job.frequency > 0 {
-		events.NewEventTimer(ctx, job.Rx, job.frequency,
+		job.startFrequencyTimer(ctx)
+	}
+	if job.schedule != nil {
+		events.NewEventSchedule(ctx, job.clock, job, job.schedule,
 			fmt.Sprintf("%s.run-every", job.Name))
 	}
 	if job.heartbeat > 0 {
-		events.NewEventTimer(ctx, job.Rx, job.heartbeat,
+		events.NewEventTimer(ctx, job.clock, job, job.heartbeat,
 			fmt.Sprintf("%s.heartbeat", job.Name))
 	}
 	if job.startTimeout > 0 {
 		timeoutName := fmt.Sprintf("%s.wait-timeout", job.Name)
-		events.NewEventTimeout(ctx, job.Rx, job.startTimeout, timeoutName)
+		events.NewEventTimeout(ctx, job.clock, job, job.startTimeout, timeoutName)
 		job.startTimeoutEvent = events.Event{events.TimerExpired, timeoutName}
 	} else {
 		job.startTimeoutEvent = events.NonEvent
 	}
 
 	go func() {
+		// the event that halts the Job isn't handled until it has stopped
+		handling := false
 		defer func() {
-			job.cleanup(ctx, cancel)
+			job.cleanup(ctx, cancel, handling)
 			completedCh <- struct{}{}
 		}()
 		for {
@@ -172,11 +298,14 @@ func (job *Job) Run(pctx context.Context, completedCh chan struct{}) {
 			select {
 			case event, ok := <-job.Rx:
 				if !ok || event == events.QuitByTest {
+					handling = ok
 					return
 				}
 				if job.processEvent(ctx, event) == jobHalt {
+					handling = true
 					return
 				}
+				job.Handled()
 			case <-ctx.Done():
 				return
 			}
@@ -184,13 +313,49 @@ func (job *Job) Run(pctx context.Context, completedCh chan struct{}) {
 	}()
 }
 
+// startFrequencyTimer starts the timer for a Job with an interval. If the
+// Job has a splay, both its first run and its timer are delayed by the
+// same random offset.
+func (job *Job) startFrequencyTimer(ctx context.Context) {
+	runEvery := fmt.Sprintf("%s.run-every", job.Name)
+	if job.splay == 0 && job.jitter == 0 {
+		events.NewEventTimer(ctx, job.clock, job, job.frequency, runEvery)
+		return
+	}
+	offset := events.RandomDuration(job.splay)
+	if offset > 0 && job.startEvent == events.GlobalStartup {
+		splayName := fmt.Sprintf("%s.splay", job.Name)
+		events.NewEventTimeout(ctx, job.clock, job, offset, splayName)
+		job.startEvent = events.Event{Code: events.TimerExpired, Source: splayName}
+	}
+	events.NewJitteredEventTimer(ctx, job.clock, job, job.frequency, offset,
+		job.jitter, runEvery)
+}
+
+// processEvent handles the event and then, if the event satisfies the
+// Job's 'when.all' or 'when.any' conditions, starts the Job. The
+// conditions see every event, including those handled by the Job itself.
 func (job *Job) processEvent(ctx context.Context, event events.Event) processEventStatus {
+	if event == job.stoppingWaitEvent {
+		// the Job that runs on our stopping event has stopped without
+		// running, so it won't be there to wait for when we stop
+		job.stoppingWaitEvent = events.NonEvent
+	}
+	conditionsMet := job.startConditions != nil && job.startConditions.update(event)
+	status := job.handleEvent(ctx, event)
+	if conditionsMet && status == jobContinue {
+		return job.onTrigger(ctx, event)
+	}
+	return status
+}
+
+func (job *Job) handleEvent(ctx context.Context, event events.Event) processEventStatus {
 	runEverySource := fmt.Sprintf("%s.run-every", job.Name)
 	heartbeatSource := fmt.Sprintf("%s.heartbeat", job.Name)
-	healthCheckName := fmt.Sprintf("check.%s", job.Name)
-	if job.healthCheckExec != nil {
-		healthCheckName = job.healthCheckExec.Name
-	}
+	restartBackoffSource := fmt.Sprintf("%s.restart-backoff", job.Name)
+	triggerSource := fmt.Sprintf("%s.trigger", job.Name)
+	delaySource := fmt.Sprintf("%s.delay", job.Name)
+	maintenanceSource := fmt.Sprintf("%s.maintenance-ttl", job.Name)
 
 	switch event {
 
@@ -203,21 +368,47 @@ func (job *Job) processEvent(ctx context.Context, event events.Event) processEve
 	case events.Event{Code: events.TimerExpired, Source: runEverySource}:
 		return job.onRunEveryTimerExpired(ctx)
 
-	case events.Event{Code: events.ExitFailed, Source: healthCheckName}:
+	case events.Event{Code: events.TimerExpired, Source: restartBackoffSource}:
+		return job.onRestartBackoffExpired(ctx)
+
+	case events.Event{Code: events.TimerExpired, Source: triggerSource}:
+		return job.onTriggerTimerExpired(ctx)
+
+	case events.Event{Code: events.TimerExpired, Source: delaySource}:
+		return job.onDelayTimerExpired(ctx)
+
+	case events.Event{Code: events.TimerExpired, Source: maintenanceSource}:
+		return job.onMaintenanceTimerExpired(ctx)
+
+	case events.Event{Code: events.ExitFailed, Source: job.healthCheckName}:
 		return job.onHealthCheckFailed(ctx)
 
-	case events.Event{Code: events.ExitSuccess, Source: healthCheckName}:
+	case events.Event{Code: events.ExitSuccess, Source: job.healthCheckName}:
 		return job.onHealthCheckPassed(ctx)
 
 	case events.Event{Code: events.Quit, Source: job.Name},
 		events.GlobalShutdown:
 		return job.onQuit(ctx)
 
-	case events.GlobalEnterMaintenance:
-		return job.onEnterMaintenance(ctx)
+	case events.Event{Code: events.Remove, Source: job.Name}:
+		return job.onRemove(ctx)
+
+	case events.GlobalEnterMaintenance,
+		events.Event{Code: events.EnterMaintenance, Source: job.Name}:
+		return job.onEnterMaintenance(ctx, event)
+
+	case events.GlobalExitMaintenance,
+		events.Event{Code: events.ExitMaintenance, Source: job.Name}:
+		return job.onExitMaintenance(ctx, event)
 
-	case events.GlobalExitMaintenance:
-		return job.onExitMaintenance(ctx)
+	case events.Event{Code: events.Start, Source: job.Name}:
+		return job.onControlStart(ctx)
+
+	case events.Event{Code: events.Stop, Source: job.Name}:
+		return job.onControlStop(ctx)
+
+	case events.Event{Code: events.Restart, Source: job.Name}:
+		return job.onControlRestart(ctx)
 
 	case events.Event{Code: events.ExitSuccess, Source: job.Name},
 		events.Event{Code: events.ExitFailed, Source: job.Name}:
@@ -225,10 +416,15 @@ func (job *Job) processEvent(ctx context.Context, event events.Event) processEve
 
 	case events.Event{Code: events.Signal, Source: "SIGHUP"},
 		events.Event{Code: events.Signal, Source: "SIGUSR2"}:
-		return job.onSignalEvent(ctx, event.Source)
+		return job.onSignalEvent(ctx, event)
 
 	case job.startEvent:
-		return job.onStartEvent(ctx)
+		return job.onTrigger(ctx, event)
+
+	default:
+		if eventMatches(job.startEvent, event) {
+			return job.onTrigger(ctx, event)
+		}
 	}
 	return jobContinue
 }
@@ -237,16 +433,25 @@ func (job *Job) processEvent(ctx context.Context, event events.Event) processEve
 func (job *Job) startJobExec(ctx context.Context) {
 	job.startTimeoutEvent = events.NonEvent
 	job.setStatus(statusUnknown)
+	job.health.start(job.clock.Now())
+	if job.backoff != nil {
+		job.backoff.started(job.clock.Now())
+	}
 	if job.exec != nil {
-		job.exec.Run(ctx, job.Publisher.Bus)
+		job.running = true
+		var env []string
+		if job.startSource != "" {
+			env = []string{triggerSourceEnv + "=" + job.startSource}
+		}
+		job.exec.RunWithEnv(ctx, job.Publisher.Bus, env)
 	}
 }
 
 func (job *Job) onHeartbeatTimerExpired(ctx context.Context) processEventStatus {
 	status := job.GetStatus()
 	if status != statusMaintenance && status != statusIdle {
-		if job.healthCheckExec != nil {
-			job.healthCheckExec.Run(ctx, job.Publisher.Bus)
+		if job.healthCheck != nil {
+			job.healthCheck.Run(ctx, job.Publisher.Bus)
 		} else if job.Service != nil {
 			// this is the case for non-checked but advertised
 			// services like the telemetry endpoint
@@ -259,41 +464,113 @@ func (job *Job) onHeartbeatTimerExpired(ctx context.Context) processEventStatus
 func (job *Job) onStartTimeoutExpired(ctx context.Context) processEventStatus {
 	job.Publish(events.Event{
 		Code: events.TimerExpired, Source: job.Name})
-	job.Rx <- events.Event{Code: events.Quit, Source: job.Name}
+	job.Receive(events.Event{Code: events.Quit, Source: job.Name})
 	return jobContinue
 }
 
 func (job *Job) onRunEveryTimerExpired(ctx context.Context) processEventStatus {
+	if job.stopped {
+		return jobContinue
+	}
 	if !job.restartPermitted() {
 		log.Debugf("interval expired but restart not permitted: %v",
 			job.Name)
 		job.startEvent = events.NonEvent
 		return jobHalt
 	}
+	if job.running {
+		switch job.concurrency {
+		case concurrencySkip:
+			job.onOverlappingRun(events.Skipped)
+			return jobContinue
+		case concurrencyQueue:
+			// at most one run is queued, and any more that come due
+			// before it starts are reported once as skipped
+			if job.runQueued && !job.runSkipped {
+				job.onOverlappingRun(events.Skipped)
+				job.runSkipped = true
+			}
+			job.runQueued = true
+			return jobContinue
+		case concurrencyReplace:
+			// the exec holds its lock until the terminated run exits,
+			// so the new run starts from onExecExit
+			if !job.runQueued {
+				job.onOverlappingRun(events.Replaced)
+				job.runQueued = true
+				job.exec.Term()
+			}
+			return jobContinue
+		}
+	}
 	job.restartsRemain--
 	job.startJobExec(ctx)
 	return jobContinue
 }
 
+// onOverlappingRun reports a run of a periodic Job that was skipped or
+// replaced because its previous run was still running
+func (job *Job) onOverlappingRun(code events.EventCode) {
+	log.Warnf("job[%s] still running at next run; run %s", job.Name,
+		strings.ToLower(code.String()))
+	overlapCollector.WithLabelValues(job.Name,
+		strings.ToLower(code.String())).Inc()
+	job.Publish(events.Event{Code: code, Source: job.Name})
+}
+
 func (job *Job) onHealthCheckFailed(ctx context.Context) processEventStatus {
-	if job.GetStatus() != statusMaintenance {
-		job.setStatus(statusUnhealthy)
-		job.Publish(events.Event{events.StatusUnhealthy, job.Name})
+	result := checkFailed
+	if check, ok := job.healthCheck.(exitCoder); ok {
+		code := check.ExitCode()
+		for _, warningCode := range job.warningExitCodes {
+			if code == warningCode {
+				result = checkWarning
+				break
+			}
+		}
 	}
+	job.onHealthCheckResult(result)
 	return jobContinue
 }
 
 func (job *Job) onHealthCheckPassed(ctx context.Context) processEventStatus {
-	if job.GetStatus() != statusMaintenance {
-		job.setStatus(statusHealthy)
-		job.Publish(events.Event{events.StatusHealthy, job.Name})
+	job.onHealthCheckResult(checkPassed)
+	return jobContinue
+}
+
+// onHealthCheckResult updates the Job's status only once the health check
+// has crossed its rise/fall threshold, and publishes an event only on a
+// real transition. A degraded Job is still usable, so moving between
+// healthy and degraded doesn't publish an event. The heartbeat keeps being
+// sent for as long as the stable status is healthy or degraded, so a single
+// failure below the 'fall' threshold doesn't let the TTL expire. Once the
+// Job is unhealthy the failure is sent as well, so that the check output
+// is visible in Consul.
+func (job *Job) onHealthCheckResult(result checkResult) {
+	current := job.GetStatus()
+	if current == statusMaintenance {
+		return
+	}
+	if check, ok := job.healthCheck.(outputter); ok {
+		job.checkOutput = check.Output()
+	}
+	status := job.health.record(result, current, job.clock.Now())
+	if status != current {
+		job.setStatus(status)
+		switch {
+		case isAvailable(status) && !isAvailable(current):
+			job.Publish(events.Event{Code: events.StatusHealthy, Source: job.Name})
+		case status == statusUnhealthy:
+			job.Publish(events.Event{Code: events.StatusUnhealthy, Source: job.Name})
+		}
+	}
+	if isAvailable(status) || status == statusUnhealthy {
 		job.SendHeartbeat()
 	}
-	return jobContinue
 }
 
 func (job *Job) onQuit(ctx context.Context) processEventStatus {
-	job.restartsRemain = 0 // no more restarts
+	job.cancelPendingStarts()
 	if (job.startEvent.Code == events.Stopping ||
 		job.startEvent.Code == events.Stopped) &&
 		job.exec != nil {
@@ -311,30 +588,96 @@ func (job *Job) onQuit(ctx context.Context) processEventStatus {
 	return jobHalt
 }
 
-func (job *Job) onEnterMaintenance(ctx context.Context) processEventStatus {
+// onRemove halts the Job when it's removed from the App. Unlike a global
+// shutdown, this halts "pre-stop" and "post-stop" style jobs too, because
+// the events they're waiting for won't arrive while the other jobs keep
+// running.
+func (job *Job) onRemove(ctx context.Context) processEventStatus {
+	job.cancelPendingStarts()
+	job.startsRemain = 0
+	job.startEvent = events.NonEvent
+	return jobHalt
+}
+
+// cancelPendingStarts cancels any restarts and any starts that are
+// waiting on a timer, and keeps the Job from being restarted
+func (job *Job) cancelPendingStarts() {
+	job.restartsRemain = 0 // no more restarts
+	job.cancelDelayedStarts()
+	job.cancelRestartBackoff()
+	job.cancelDeferredStart()
+}
+
+// onEnterMaintenance puts the Job into maintenance, either along with
+// all the other jobs or on its own. A Job that starts on this event (or
+// on the same event for another job) is started.
+func (job *Job) onEnterMaintenance(ctx context.Context, event events.Event) processEventStatus {
 	job.setStatus(statusMaintenance)
+	current := job.startMaintenance()
+	if current.ttl > 0 {
+		events.NewEventTimeout(ctx, job.clock, job, current.ttl,
+			fmt.Sprintf("%s.maintenance-ttl", job.Name))
+	}
 	if job.Service != nil {
-		job.Service.MarkForMaintenance()
+		job.Service.MarkForMaintenance(current.reason)
 	}
-	if job.startEvent == events.GlobalEnterMaintenance {
-		return job.onStartEvent(ctx)
+	if eventMatches(job.startEvent, event) {
+		return job.onTrigger(ctx, event)
 	}
 	return jobContinue
 }
 
-func (job *Job) onExitMaintenance(ctx context.Context) processEventStatus {
+func (job *Job) onExitMaintenance(ctx context.Context, event events.Event) processEventStatus {
+	inMaintenance := job.GetStatus() == statusMaintenance
 	job.setStatus(statusUnknown)
-	if job.startEvent == events.GlobalExitMaintenance {
-		return job.onStartEvent(ctx)
+	job.health.reset()
+	job.clearMaintenance()
+	// a global exit reaches every job, but only a job that was in
+	// maintenance has a Consul maintenance mode to clear
+	if job.Service != nil && inMaintenance {
+		job.Service.ClearMaintenance()
+	}
+	if eventMatches(job.startEvent, event) {
+		return job.onTrigger(ctx, event)
+	}
+	return jobContinue
+}
+
+// onMaintenanceTimerExpired takes the Job out of maintenance mode once its
+// TTL has passed, by publishing the same event as the control plane would
+// so that other jobs can react to it
+func (job *Job) onMaintenanceTimerExpired(ctx context.Context) processEventStatus {
+	if job.GetStatus() == statusMaintenance && job.maintenanceExpired() {
+		log.Infof("job[%s] maintenance mode expired", job.Name)
+		job.Publish(events.Event{Code: events.ExitMaintenance, Source: job.Name})
 	}
 	return jobContinue
 }
 
 func (job *Job) onExecExit(ctx context.Context) processEventStatus {
-	if job.frequency > 0 {
+	job.running = false
+	job.runSkipped = false
+	if job.restartRequested {
+		job.restartRequested = false
+		job.runQueued = false
+		return job.startFromControl(ctx)
+	}
+	if job.stopped {
+		return jobContinue // wait to be started through the control plane
+	}
+	if job.isPeriodic() {
+		if job.runQueued && job.restartPermitted() {
+			job.runQueued = false
+			job.restartsRemain--
+			job.startJobExec(ctx)
+		}
 		return jobContinue // periodic jobs ignore previous events
 	}
 	if job.restartPermitted() {
+		if job.backoff != nil {
+			job.restartAfterBackoff(ctx)
+			return jobContinue
+		}
 		job.restartsRemain--
 		job.startJobExec(ctx)
 		return jobContinue
@@ -348,15 +691,100 @@ func (job *Job) onExecExit(ctx context.Context) processEventStatus {
 	return jobHalt
 }
 
-func (job *Job) onSignalEvent(ctx context.Context, sig string) processEventStatus {
-	if job.startEvent.Code == events.Signal &&
-		job.startEvent.Source == sig {
-		job.startJobExec(ctx)
+// restartAfterBackoff schedules the restart of the Job's exec after its
+// backoff delay. The restart has its own context so that it can be
+// cancelled if the Job is stopped, started, or told to quit before it fires.
+func (job *Job) restartAfterBackoff(ctx context.Context) {
+	delay := job.backoff.next(job.clock.Now())
+	log.Infof("job[%s] exited; restarting in %v", job.Name, delay)
+	job.cancelRestartBackoff()
+	job.backoffCtx, job.cancelBackoff = context.WithCancel(ctx)
+	events.NewEventTimeout(job.backoffCtx, job.clock, job, delay,
+		fmt.Sprintf("%s.restart-backoff", job.Name))
+}
+
+func (job *Job) onRestartBackoffExpired(ctx context.Context) processEventStatus {
+	if job.cancelBackoff == nil {
+		return jobContinue // cancelled, but the timer had already fired
+	}
+	job.cancelRestartBackoff()
+	if job.stopped || job.running || !job.restartPermitted() {
+		return jobContinue
+	}
+	job.restartsRemain--
+	job.startJobExec(ctx)
+	return jobContinue
+}
+
+// cancelRestartBackoff cancels the restart after a backoff delay, if it
+// hasn't fired yet
+func (job *Job) cancelRestartBackoff() {
+	if job.cancelBackoff != nil {
+		job.cancelBackoff()
+		job.backoffCtx, job.cancelBackoff = nil, nil
+	}
+}
+
+// onSignalEvent starts a Job triggered by the signal just like a Job
+// triggered by any other event, so that its debounce, throttle and delay
+// apply and a stopped Job stays stopped
+func (job *Job) onSignalEvent(ctx context.Context, event events.Event) processEventStatus {
+	if job.startEvent == event {
+		return job.onTrigger(ctx, event)
 	}
 	return jobContinue
 }
 
-func (job *Job) onStartEvent(ctx context.Context) processEventStatus {
+// onTrigger starts the Job in response to its start event, unless the
+// Job has a debounce or throttle that defers the start
+func (job *Job) onTrigger(ctx context.Context, event events.Event) processEventStatus {
+	if job.stopped {
+		log.Debugf("job[%s] stopped; ignoring start event %v", job.Name, event)
+		return jobContinue
+	}
+	if job.startLimiter == nil {
+		return job.onStartEvent(ctx, event)
+	}
+	start, wait := job.startLimiter.trigger(event, job.clock.Now())
+	switch {
+	case start:
+		return job.onStartEvent(ctx, event)
+	case wait > 0:
+		events.NewEventTimeout(ctx, job.clock, job, wait,
+			fmt.Sprintf("%s.trigger", job.Name))
+	default:
+		limit := job.startLimiter.limit()
+		log.Debugf("job[%s] start event %v collapsed by %s", job.Name, event, limit)
+		collapsedCollector.WithLabelValues(job.Name, limit).Inc()
+	}
+	return jobContinue
+}
+
+func (job *Job) onTriggerTimerExpired(ctx context.Context) processEventStatus {
+	if job.stopped || !job.startLimiter.pending {
+		return jobContinue // cancelled, but the timer had already fired
+	}
+	start, wait := job.startLimiter.expired(job.clock.Now())
+	if !start {
+		events.NewEventTimeout(ctx, job.clock, job, wait,
+			fmt.Sprintf("%s.trigger", job.Name))
+		return jobContinue
+	}
+	return job.onStartEvent(ctx, job.startLimiter.pendingEvent)
+}
+
+// cancelDeferredStart cancels a start that's deferred by the Job's
+// debounce or throttle
+func (job *Job) cancelDeferredStart() {
+	if job.startLimiter != nil {
+		job.startLimiter.cancel()
+	}
+}
+
+// onStartEvent starts the Job's exec in response to the event that
+// triggered it. The event's source is passed to the exec (and to any
+// restarts of it) in the environment.
+func (job *Job) onStartEvent(ctx context.Context, event events.Event) processEventStatus {
 	if job.startsRemain == 0 {
 		job.startEvent = events.NonEvent
 		return jobHalt
@@ -369,12 +797,129 @@ func (job *Job) onStartEvent(ctx context.Context) processEventStatus {
 			// prevent ourselves from receiving the start event again
 			// if it fires while we're still running the job's exec
 			job.startEvent = events.NonEvent
+			job.startConditions = nil
 		}
 	}
+	job.startSource = event.Source
+	if job.startDelay > 0 {
+		job.startAfterDelay(ctx)
+		return jobContinue
+	}
+	job.startJobExec(ctx)
+	return jobContinue
+}
+
+// startAfterDelay schedules the start of the Job's exec after its delay.
+// All the delayed starts share a context so that they can be cancelled
+// together if the Job is told to quit before they fire.
+func (job *Job) startAfterDelay(ctx context.Context) {
+	// the start event has arrived, so it can no longer time out
+	job.startTimeoutEvent = events.NonEvent
+	if job.delayCtx == nil {
+		job.delayCtx, job.cancelDelay = context.WithCancel(ctx)
+	}
+	job.delayedStarts++
+	events.NewEventTimeout(job.delayCtx, job.clock, job, job.startDelay,
+		fmt.Sprintf("%s.delay", job.Name))
+}
+
+func (job *Job) onDelayTimerExpired(ctx context.Context) processEventStatus {
+	if job.delayedStarts == 0 {
+		return jobContinue // cancelled, but the timer had already fired
+	}
+	job.delayedStarts--
 	job.startJobExec(ctx)
 	return jobContinue
 }
 
+// cancelDelayedStarts cancels any delayed starts that haven't fired yet
+func (job *Job) cancelDelayedStarts() {
+	if job.cancelDelay != nil {
+		job.cancelDelay()
+		job.delayCtx, job.cancelDelay = nil, nil
+	}
+	job.delayedStarts = 0
+}
+
+// onControlStart starts a Job that was stopped through the control plane,
+// or that hasn't started yet, unless its exec is already running
+func (job *Job) onControlStart(ctx context.Context) processEventStatus {
+	job.stopped = false
+	job.cancelRestartBackoff()
+	if job.running {
+		log.Infof("job[%s] is already running", job.Name)
+		return jobContinue
+	}
+	return job.startFromControl(ctx)
+}
+
+// onControlStop terminates the Job's exec, if it's running, and keeps the
+// Job from starting again until it's started through the control plane
+func (job *Job) onControlStop(ctx context.Context) processEventStatus {
+	job.stopped = true
+	job.restartRequested = false
+	job.runQueued = false
+	job.cancelDelayedStarts()
+	job.cancelRestartBackoff()
+	job.cancelDeferredStart()
+	job.setStatus(statusIdle)
+	if job.running {
+		log.Infof("job[%s] stopping through control plane", job.Name)
+		job.exec.Term()
+	}
+	return jobContinue
+}
+
+// onControlRestart terminates the Job's exec, if it's running, and then
+// starts it again
+func (job *Job) onControlRestart(ctx context.Context) processEventStatus {
+	job.stopped = false
+	job.cancelRestartBackoff()
+	if !job.running {
+		return job.startFromControl(ctx)
+	}
+	if !job.isWaitingToStart() && !job.restartPermitted() {
+		log.Warnf("job[%s] can't be restarted: no restarts remain", job.Name)
+		return jobContinue
+	}
+	log.Infof("job[%s] restarting through control plane", job.Name)
+	job.restartRequested = true
+	job.exec.Term()
+	return jobContinue
+}
+
+// startFromControl starts the Job's exec in response to the control
+// plane. If the Job is still waiting on its start event (or starts on
+// each one) this counts as one of its starts. Otherwise it counts as a
+// restart, and the Job won't start if it has no restarts remaining.
+func (job *Job) startFromControl(ctx context.Context) processEventStatus {
+	if job.exec == nil {
+		return jobContinue
+	}
+	if job.isWaitingToStart() {
+		return job.onStartEvent(ctx,
+			events.Event{Code: events.Start, Source: "control"})
+	}
+	if !job.restartPermitted() {
+		log.Warnf("job[%s] can't be started: no restarts remain", job.Name)
+		return jobContinue
+	}
+	job.restartsRemain--
+	job.startSource = "control"
+	job.startJobExec(ctx)
+	return jobContinue
+}
+
+// isWaitingToStart returns true if the Job can still be started by its
+// start event, rather than only restarted
+func (job *Job) isWaitingToStart() bool {
+	if job.isPeriodic() {
+		return false
+	}
+	return (job.startEvent != events.NonEvent && job.startsRemain != 0) ||
+		job.startConditions != nil
+}
+
 func (job *Job) restartPermitted() bool {
 	if job.restartLimit == unlimited || job.restartsRemain > 0 {
 		return true
@@ -384,16 +929,21 @@ func (job *Job) restartPermitted() bool {
 
 // cleanup fires the Stopping event and will wait to receive a stoppingWaitEvent
 // if one is configured. cleans up registration to event bus and closes all
-// channels and contexts when done.
-func (job *Job) cleanup(ctx context.Context, cancel context.CancelFunc) {
+// channels and contexts when done. If the Job is handling the event that
+// halted it, that's handled once the Job has published Stopped.
+func (job *Job) cleanup(ctx context.Context, cancel context.CancelFunc, handling bool) {
 	stoppingTimeout := fmt.Sprintf("%s.stopping-timeout", job.Name)
 	job.Publish(events.Event{Code: events.Stopping, Source: job.Name})
 	if job.stoppingWaitEvent != events.NonEvent {
 		if job.stoppingTimeout > 0 {
 			// not having this set is a programmer error not a runtime error
-			events.NewEventTimeout(ctx, job.Rx,
+			events.NewEventTimeout(ctx, job.clock, job,
 				job.stoppingTimeout, stoppingTimeout)
 		}
+		if handling {
+			// don't hold up a simulation's clock while we wait
+			job.Handled()
+		}
 	loop:
 		for {
 			event := <-job.Rx
@@ -403,7 +953,9 @@ func (job *Job) cleanup(ctx context.Context, cancel context.CancelFunc) {
 			case events.Event{events.Stopping, stoppingTimeout}:
 				break loop
 			}
+			job.Handled()
 		}
+		handling = true // the event we were waiting for
 	}
 	cancel()
 	if job.Service != nil {
@@ -413,6 +965,9 @@ func (job *Job) cleanup(ctx context.Context, cancel context.CancelFunc) {
 	job.Unregister()
 	job.setComplete()
 	job.Publish(events.Event{Code: events.Stopped, Source: job.Name})
+	if handling {
+		job.Handled()
+	}
 }
 
 // String implements the stdlib fmt.Stringer interface for pretty-printing
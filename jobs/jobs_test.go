@@ -9,7 +9,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/joyent/containerpilot/discovery"
 	"github.com/joyent/containerpilot/events"
+	"github.com/joyent/containerpilot/tests/mocks"
 )
 
 func TestJobRunSafeClose(t *testing.T) {
@@ -86,6 +88,380 @@ func TestJobRunStartupTimeout(t *testing.T) {
 }
 
 // A Job should not timeout if started before the startupTimeout
+func TestJobRunWhenConditions(t *testing.T) {
+	testFunc := func(t *testing.T, when *WhenConfig, published ...events.Event) map[events.Event]int {
+		bus := events.NewEventBus()
+		stopCh := make(chan struct{}, 1)
+		cfg := &Config{Name: "myjob", Exec: "true", When: when}
+		if err := cfg.Validate(noop); err != nil {
+			t.Fatal(err)
+		}
+		job := NewJob(cfg)
+		job.Subscribe(bus)
+		job.Register(bus)
+		ctx, cancel := context.WithCancel(context.Background())
+		job.Run(ctx, stopCh)
+		for _, event := range published {
+			job.Publish(event)
+		}
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+		bus.Wait()
+		got := map[events.Event]int{}
+		for _, result := range bus.DebugEvents() {
+			got[result]++
+		}
+		return got
+	}
+	conditions := []ConditionConfig{
+		{Source: "db", Event: "healthy"},
+		{Source: "migrations", Event: "exitSuccess"},
+	}
+	dbHealthy := events.Event{Code: events.StatusHealthy, Source: "db"}
+	migrated := events.Event{Code: events.ExitSuccess, Source: "migrations"}
+	ran := events.Event{Code: events.ExitSuccess, Source: "myjob"}
+	timedOut := events.Event{Code: events.TimerExpired, Source: "myjob"}
+
+	t.Run("all satisfied", func(t *testing.T) {
+		got := testFunc(t, &WhenConfig{All: conditions}, dbHealthy, migrated, dbHealthy)
+		assert.Equal(t, 1, got[ran], "expected exactly one run: %v", got)
+	})
+	t.Run("all unsatisfied", func(t *testing.T) {
+		got := testFunc(t, &WhenConfig{All: conditions}, dbHealthy, dbHealthy)
+		assert.Equal(t, 0, got[ran], "expected no runs: %v", got)
+	})
+	t.Run("all flipped back", func(t *testing.T) {
+		dbUnhealthy := events.Event{Code: events.StatusUnhealthy, Source: "db"}
+		got := testFunc(t, &WhenConfig{All: conditions}, dbHealthy, dbUnhealthy, migrated)
+		assert.Equal(t, 0, got[ran], "expected no runs: %v", got)
+	})
+	t.Run("any satisfied", func(t *testing.T) {
+		got := testFunc(t, &WhenConfig{Any: conditions}, migrated)
+		assert.Equal(t, 1, got[ran], "expected exactly one run: %v", got)
+	})
+	t.Run("any sees events the job handles", func(t *testing.T) {
+		got := testFunc(t, &WhenConfig{Any: []ConditionConfig{
+			{Source: "global", Event: "enterMaintenance"},
+		}}, events.GlobalEnterMaintenance)
+		assert.Equal(t, 1, got[ran], "expected exactly one run: %v", got)
+	})
+	t.Run("timeout", func(t *testing.T) {
+		got := testFunc(t, &WhenConfig{All: conditions, Timeout: "100ms"}, dbHealthy)
+		assert.Equal(t, 0, got[ran], "expected no runs: %v", got)
+		assert.Equal(t, 1, got[timedOut], "expected timeout: %v", got)
+	})
+}
+
+func TestJobRunGlobSource(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "myjob",
+		// fails unless the exec gets the source that triggered it
+		Exec: []string{"sh", "-c",
+			"test \"$CONTAINERPILOT_TRIGGER_SOURCE\" = watch.api-1"},
+		When: &WhenConfig{Source: "watch.api-*", Each: "changed"},
+	}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	job.Publish(events.Event{Code: events.StatusChanged, Source: "watch.web"})
+	job.Publish(events.Event{Code: events.StatusChanged, Source: "watch.api-1"})
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: "myjob"}],
+		"expected exactly one successful run: %v", got)
+	assert.Equal(t, 0, got[events.Event{Code: events.ExitFailed, Source: "myjob"}],
+		"expected no failed runs: %v", got)
+}
+
+// A Job with a pattern source shouldn't be triggered by health checks,
+// even though their events have the same code as a job's
+func TestJobRunGlobSourceIgnoresHealthChecks(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 2)
+	dbCfg := &Config{
+		Name:   "db",
+		Exec:   "true",
+		Health: &HealthConfig{CheckExec: "false", Heartbeat: 10, TTL: 50},
+	}
+	alertCfg := &Config{
+		Name: "alert",
+		Exec: "true",
+		When: &WhenConfig{Source: "*", Each: "exitFailed"},
+	}
+	for _, cfg := range []*Config{dbCfg, alertCfg} {
+		if err := cfg.Validate(noop); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, alert := NewJob(dbCfg), NewJob(alertCfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, job := range []*Job{db, alert} {
+		job.Subscribe(bus)
+		job.Register(bus)
+		job.Run(ctx, stopCh)
+	}
+	db.healthCheck.Run(ctx, bus)
+	time.Sleep(100 * time.Millisecond)
+	alert.Publish(events.Event{Code: events.ExitFailed, Source: "other"})
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitFailed, Source: "check.db"}],
+		"expected failed health check: %v", got)
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: "alert"}],
+		"expected only the failed job to trigger the alert: %v", got)
+}
+
+func TestJobRunDebounce(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Source: "watch.app", Each: "changed", Debounce: "100ms"},
+	}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	changed := events.Event{Code: events.StatusChanged, Source: "watch.app"}
+	for i := 0; i < 5; i++ {
+		job.Publish(changed)
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 5, got[changed])
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: "myjob"}],
+		"expected burst of events to collapse into one run: %v", got)
+}
+
+// A Job triggered by a signal should be debounced like any other Job,
+// and should get the signal as its trigger source
+func TestJobRunSignalDebounce(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "myjob",
+		// fails unless the exec gets the source that triggered it
+		Exec: []string{"sh", "-c",
+			"test \"$CONTAINERPILOT_TRIGGER_SOURCE\" = SIGHUP"},
+		When: &WhenConfig{Source: "SIGHUP", Debounce: "100ms"},
+	}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	sighup := events.Event{Code: events.Signal, Source: "SIGHUP"}
+	for i := 0; i < 5; i++ {
+		job.Publish(sighup)
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: "myjob"}],
+		"expected burst of signals to collapse into one run: %v", got)
+	assert.Equal(t, 0, got[events.Event{Code: events.ExitFailed, Source: "myjob"}],
+		"expected no failed runs: %v", got)
+}
+
+// A Job stopped through the control plane while its start is deferred by
+// a debounce shouldn't start when the debounce expires
+func TestJobRunDebounceStopped(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Source: "watch.app", Each: "changed", Debounce: "100ms"},
+	}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	job.Publish(events.Event{Code: events.StatusChanged, Source: "watch.app"})
+	time.Sleep(20 * time.Millisecond)
+	job.Publish(events.Event{Code: events.Stop, Source: "myjob"})
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 0, got[events.Event{Code: events.ExitSuccess, Source: "myjob"}],
+		"expected no run after stop: %v", got)
+}
+
+func TestJobRunDelay(t *testing.T) {
+	testFunc := func(t *testing.T, when *WhenConfig, wait time.Duration, published ...events.Event) map[events.Event]int {
+		bus := events.NewEventBus()
+		stopCh := make(chan struct{}, 1)
+		cfg := &Config{
+			Name: "myjob",
+			Exec: "true",
+			When: when,
+		}
+		if err := cfg.Validate(noop); err != nil {
+			t.Fatal(err)
+		}
+		job := NewJob(cfg)
+		job.Subscribe(bus)
+		job.Register(bus)
+		ctx, cancel := context.WithCancel(context.Background())
+		job.Run(ctx, stopCh)
+		for _, event := range published {
+			job.Publish(event)
+		}
+		time.Sleep(wait)
+		cancel()
+		bus.Wait()
+		got := map[events.Event]int{}
+		for _, result := range bus.DebugEvents() {
+			got[result]++
+		}
+		return got
+	}
+	ran := events.Event{Code: events.ExitSuccess, Source: "myjob"}
+	healthy := events.Event{Code: events.StatusHealthy, Source: "primary"}
+	quit := events.Event{Code: events.Quit, Source: "myjob"}
+	sighup := events.Event{Code: events.Signal, Source: "SIGHUP"}
+	onHealthy := &WhenConfig{Source: "primary", Once: "healthy", Delay: "100ms"}
+	onSignal := &WhenConfig{Source: "SIGHUP", Delay: "100ms"}
+
+	t.Run("delayed", func(t *testing.T) {
+		got := testFunc(t, onHealthy, 200*time.Millisecond, healthy)
+		assert.Equal(t, 1, got[ran], "expected exactly one run: %v", got)
+	})
+	t.Run("before delay", func(t *testing.T) {
+		got := testFunc(t, onHealthy, 50*time.Millisecond, healthy)
+		assert.Equal(t, 0, got[ran], "job should not start before its delay: %v", got)
+	})
+	t.Run("cancelled by quit", func(t *testing.T) {
+		got := testFunc(t, onHealthy, 200*time.Millisecond, healthy, quit)
+		assert.Equal(t, 0, got[ran], "expected no runs: %v", got)
+	})
+	t.Run("cancelled by shutdown", func(t *testing.T) {
+		got := testFunc(t, onHealthy, 200*time.Millisecond, healthy, events.GlobalShutdown)
+		assert.Equal(t, 0, got[ran], "expected no runs: %v", got)
+	})
+	t.Run("signal", func(t *testing.T) {
+		got := testFunc(t, onSignal, 50*time.Millisecond, sighup)
+		assert.Equal(t, 0, got[ran], "job should not start before its delay: %v", got)
+		got = testFunc(t, onSignal, 200*time.Millisecond, sighup)
+		assert.Equal(t, 1, got[ran], "expected exactly one run: %v", got)
+	})
+}
+
+func TestJobRunControl(t *testing.T) {
+	testFunc := func(t *testing.T, cfg *Config, published ...events.Event) map[events.Event]int {
+		bus := events.NewEventBus()
+		stopCh := make(chan struct{}, 1)
+		if err := cfg.Validate(noop); err != nil {
+			t.Fatal(err)
+		}
+		job := NewJob(cfg)
+		job.Subscribe(bus)
+		job.Register(bus)
+		ctx, cancel := context.WithCancel(context.Background())
+		job.Run(ctx, stopCh)
+		for _, event := range published {
+			job.Publish(event)
+			time.Sleep(100 * time.Millisecond)
+		}
+		cancel()
+		bus.Wait()
+		got := map[events.Event]int{}
+		for _, result := range bus.DebugEvents() {
+			got[result]++
+		}
+		return got
+	}
+	ran := events.Event{Code: events.ExitSuccess, Source: "myjob"}
+	killed := events.Event{Code: events.ExitFailed, Source: "myjob"}
+	start := events.Event{Code: events.Start, Source: "myjob"}
+	stop := events.Event{Code: events.Stop, Source: "myjob"}
+	restart := events.Event{Code: events.Restart, Source: "myjob"}
+	healthy := events.Event{Code: events.StatusHealthy, Source: "other"}
+	sighup := events.Event{Code: events.Signal, Source: "SIGHUP"}
+
+	t.Run("stop", func(t *testing.T) {
+		cfg := &Config{Name: "myjob", Exec: "sleep 10", Restarts: "unlimited"}
+		got := testFunc(t, cfg, events.GlobalStartup, stop)
+		assert.Equal(t, 1, got[killed], "expected job to be stopped: %v", got)
+		assert.Equal(t, 1, got[stop], "expected no restart after stop: %v", got)
+	})
+	t.Run("restart respects restart limit", func(t *testing.T) {
+		cfg := &Config{Name: "myjob", Exec: "sleep 10", Restarts: 1}
+		got := testFunc(t, cfg, events.GlobalStartup, restart, restart)
+		// one exit for the restart, and one when the restarted run is
+		// killed at the end of the test
+		assert.Equal(t, 2, got[killed], "expected only one restart: %v", got)
+	})
+	t.Run("start before trigger", func(t *testing.T) {
+		cfg := &Config{Name: "myjob", Exec: "true",
+			When: &WhenConfig{Source: "other", Once: "healthy"}}
+		got := testFunc(t, cfg, start, healthy)
+		assert.Equal(t, 1, got[ran], "expected start to count as the trigger: %v", got)
+	})
+	t.Run("stopped ignores triggers", func(t *testing.T) {
+		cfg := &Config{Name: "myjob", Exec: "true",
+			When: &WhenConfig{Source: "other", Each: "healthy"}}
+		got := testFunc(t, cfg, stop, healthy, healthy, start)
+		assert.Equal(t, 1, got[ran], "expected only the start to run the job: %v", got)
+	})
+	t.Run("stopped ignores signals", func(t *testing.T) {
+		cfg := &Config{Name: "myjob", Exec: "true",
+			When: &WhenConfig{Source: "SIGHUP"}}
+		got := testFunc(t, cfg, stop, sighup, sighup)
+		assert.Equal(t, 0, got[ran], "expected no runs: %v", got)
+	})
+	t.Run("stopped ignores conditions", func(t *testing.T) {
+		cfg := &Config{Name: "myjob", Exec: "true",
+			When: &WhenConfig{Any: []ConditionConfig{{Source: "other", Event: "healthy"}}}}
+		got := testFunc(t, cfg, stop, healthy)
+		assert.Equal(t, 0, got[ran], "expected no runs: %v", got)
+	})
+}
+
 func TestJobRunStartupNoTimeout(t *testing.T) {
 	bus := events.NewEventBus()
 	stopCh := make(chan struct{}, 1)
@@ -163,6 +539,78 @@ func TestJobRunRestarts(t *testing.T) {
 	runRestartsTest(nil, 1)
 }
 
+// A Job with a restart policy should wait out the backoff delay between
+// restarts rather than restarting immediately
+func TestJobRunRestartBackoff(t *testing.T) {
+	runBackoffTest := func(delay string, expected int) {
+		bus := events.NewEventBus()
+		stopCh := make(chan struct{}, 1)
+		cfg := &Config{
+			Name:          "myjob",
+			Exec:          []string{"./testdata/test.sh", "doStuff", "runBackoffTest"},
+			Restarts:      3,
+			RestartPolicy: &RestartPolicyConfig{Delay: delay, Multiplier: 1},
+		}
+		cfg.Validate(noop)
+		job := NewJob(cfg)
+		job.Subscribe(bus)
+		job.Register(bus)
+		ctx, cancel := context.WithCancel(context.Background())
+		job.Run(ctx, stopCh)
+		job.Publish(events.GlobalStartup)
+		time.Sleep(400 * time.Millisecond)
+		cancel()
+		bus.Wait()
+		results := bus.DebugEvents()
+		got := 0
+		for _, result := range results {
+			if result == (events.Event{Code: events.ExitSuccess, Source: "myjob"}) {
+				got++
+			}
+		}
+		if got != expected {
+			t.Fatalf("expected %d runs with delay %s but got %d\n%v",
+				expected, delay, got, results)
+		}
+	}
+	runBackoffTest("50ms", 4)
+	runBackoffTest("10s", 1)
+}
+
+// A Job stopped through the control plane while it waits out its backoff
+// delay shouldn't be restarted when the delay expires
+func TestJobRunRestartBackoffStopped(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name:          "myjob",
+		Exec:          "true",
+		Restarts:      "unlimited",
+		RestartPolicy: &RestartPolicyConfig{Delay: "200ms", Multiplier: 1},
+	}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	job.Publish(events.GlobalStartup)
+	time.Sleep(100 * time.Millisecond)
+	job.Publish(events.Event{Code: events.Stop, Source: "myjob"})
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	got := 0
+	for _, result := range bus.DebugEvents() {
+		if result == (events.Event{Code: events.ExitSuccess, Source: "myjob"}) {
+			got++
+		}
+	}
+	assert.Equal(t, 1, got, "expected no restart after stop")
+}
+
 func TestJobRunPeriodic(t *testing.T) {
 	bus := events.NewEventBus()
 	stopCh := make(chan struct{}, 1)
@@ -205,6 +653,128 @@ func TestJobRunPeriodic(t *testing.T) {
 	}
 }
 
+// A periodic Job with a splay should delay its first run
+func TestJobRunPeriodicSplay(t *testing.T) {
+	// the random splay is deterministic under a seed
+	events.SeedRandom(1)
+	if offset := events.RandomDuration(10 * time.Second); offset < time.Second {
+		t.Fatalf("test requires a seeded splay > 1s but got %v", offset)
+	}
+	events.SeedRandom(1)
+
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "myjob",
+		Exec: []string{"./testdata/test.sh", "doStuff", "runPeriodicSplayTest"},
+		When: &WhenConfig{Frequency: "100ms", Splay: "10s"},
+	}
+	cfg.Validate(noop)
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	job.Publish(events.GlobalStartup)
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	results := bus.DebugEvents()
+	for _, result := range results {
+		if result.Source == "myjob" && (result.Code == events.ExitSuccess ||
+			result.Code == events.ExitFailed) {
+			t.Fatalf("expected no runs during splay but got:\n%v", results)
+		}
+	}
+}
+
+func TestJobRunPeriodicConcurrency(t *testing.T) {
+	// each run sleeps for 400ms but the job runs every 250ms, so
+	// every other tick overlaps with a run that's still running
+	testFunc := func(t *testing.T, policy string) map[events.Event]int {
+		bus := events.NewEventBus()
+		stopCh := make(chan struct{}, 1)
+		cfg := &Config{
+			Name:        "myjob",
+			Exec:        []string{"sleep", "0.4"},
+			ExecTimeout: "2s",
+			When: &WhenConfig{
+				Frequency:   "250ms",
+				Concurrency: policy,
+			},
+		}
+		if err := cfg.Validate(noop); err != nil {
+			t.Fatal(err)
+		}
+		job := NewJob(cfg)
+		job.Subscribe(bus)
+		job.Register(bus)
+		ctx, cancel := context.WithCancel(context.Background())
+		job.Run(ctx, stopCh)
+		job.Publish(events.GlobalStartup)
+		time.Sleep(1100 * time.Millisecond)
+		cancel()
+		bus.Wait()
+		got := map[events.Event]int{}
+		for _, result := range bus.DebugEvents() {
+			got[result]++
+		}
+		return got
+	}
+	skipped := events.Event{Code: events.Skipped, Source: "myjob"}
+	replaced := events.Event{Code: events.Replaced, Source: "myjob"}
+
+	t.Run("skip", func(t *testing.T) {
+		got := testFunc(t, "skip")
+		assert.True(t, got[skipped] > 0, "expected skipped runs: %v", got)
+		assert.Equal(t, 0, got[replaced])
+	})
+	t.Run("queue", func(t *testing.T) {
+		got := testFunc(t, "queue")
+		assert.Equal(t, 0, got[replaced])
+		assert.True(t,
+			got[events.Event{Code: events.ExitSuccess, Source: "myjob"}] >= 2,
+			"expected queued runs to complete: %v", got)
+	})
+	t.Run("replace", func(t *testing.T) {
+		got := testFunc(t, "replace")
+		assert.True(t, got[replaced] > 0, "expected replaced runs: %v", got)
+		assert.Equal(t, 0, got[skipped])
+		assert.Equal(t, 0,
+			got[events.Event{Code: events.ExitSuccess, Source: "myjob"}],
+			"no run should have completed: %v", got)
+	})
+}
+
+// A periodic Job with the queue policy holds at most one queued run, and
+// reports the runs dropped while one is queued as a single skipped run
+func TestJobRunPeriodicQueueSkipped(t *testing.T) {
+	bus := events.NewEventBus()
+	cfg := &Config{
+		Name: "myjob",
+		Exec: "true",
+		When: &WhenConfig{Frequency: "1h", Concurrency: "queue"},
+	}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Register(bus)
+	job.running = true // the previous run hasn't exited
+	for i := 0; i < 4; i++ {
+		job.onRunEveryTimerExpired(context.Background())
+	}
+	assert.True(t, job.runQueued, "expected a queued run")
+	job.Unregister()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 1, got[events.Event{Code: events.Skipped, Source: "myjob"}],
+		"expected one skipped run: %v", got)
+}
+
 func TestJobMaintenance(t *testing.T) {
 	testFunc := func(t *testing.T, startingState JobStatus, event events.Event) JobStatus {
 		bus := events.NewEventBus()
@@ -266,6 +836,282 @@ func TestJobMaintenance(t *testing.T) {
 		assert.Equal(t, statusHealthy, status,
 			"job status after passing check out of maintenance")
 	})
+
+	t.Run("enter job maintenance", func(t *testing.T) {
+		status := testFunc(t, statusUnknown,
+			events.Event{events.EnterMaintenance, "myjob"})
+		assert.Equal(t, statusMaintenance, status,
+			"job status after entering job maintenance mode")
+	})
+
+	t.Run("other job maintenance no change", func(t *testing.T) {
+		status := testFunc(t, statusUnknown,
+			events.Event{events.EnterMaintenance, "otherjob"})
+		assert.Equal(t, statusUnknown, status,
+			"job status after another job entered maintenance mode")
+	})
+
+	t.Run("exit job maintenance", func(t *testing.T) {
+		status := testFunc(t, statusMaintenance,
+			events.Event{events.ExitMaintenance, "myjob"})
+		assert.Equal(t, statusUnknown, status,
+			"job status after exiting job maintenance")
+	})
+}
+
+// A Job should only take its service out of Consul's maintenance mode
+// if it was in maintenance.
+func TestJobExitMaintenanceClearsService(t *testing.T) {
+	testFunc := func(t *testing.T, startingState JobStatus, event events.Event) int {
+		bus := events.NewEventBus()
+		stopCh := make(chan struct{}, 1)
+		cfg := &Config{Name: "myjob", Port: 80, Exec: "true",
+			Health: &HealthConfig{CheckExec: "true", Heartbeat: 10, TTL: 50}}
+		backend := &maintenanceRecorder{}
+		if err := cfg.Validate(backend); err != nil {
+			t.Fatal(err)
+		}
+		job := NewJob(cfg)
+		job.setStatus(startingState)
+		job.Subscribe(bus)
+		job.Register(bus)
+		job.Run(context.Background(), stopCh)
+		bus.Publish(event)
+		bus.Publish(events.QuitByTest)
+		bus.Wait()
+		return backend.cleared
+	}
+	assert.Equal(t, 1, testFunc(t, statusMaintenance, events.GlobalExitMaintenance))
+	assert.Equal(t, 0, testFunc(t, statusHealthy, events.GlobalExitMaintenance))
+	assert.Equal(t, 1, testFunc(t, statusMaintenance,
+		events.Event{Code: events.ExitMaintenance, Source: "myjob"}))
+}
+
+func TestJobMaintenanceTTL(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{Name: "myjob", Exec: "true"}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	job.RequestMaintenance("upgrading", 100*time.Millisecond)
+	job.Publish(events.Event{Code: events.EnterMaintenance, Source: "myjob"})
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, statusMaintenance, job.GetStatus())
+	reason, until := job.GetMaintenance()
+	assert.Equal(t, "upgrading", reason)
+	assert.False(t, until.IsZero(), "expected maintenance to expire")
+
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, statusUnknown, job.GetStatus(), "expected maintenance to expire")
+	reason, until = job.GetMaintenance()
+	assert.Equal(t, "", reason)
+	assert.True(t, until.IsZero())
+	cancel()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitMaintenance, Source: "myjob"}],
+		"expected expiry to publish exitMaintenance: %v", got)
+}
+
+func TestJobRunWhenJobMaintenance(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "drain",
+		Exec: "true",
+		When: &WhenConfig{Source: "admin", Each: "enterMaintenance"},
+	}
+	if err := cfg.Validate(noop); err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Run(ctx, stopCh)
+	job.Publish(events.GlobalEnterMaintenance)
+	job.Publish(events.Event{Code: events.EnterMaintenance, Source: "admin"})
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	bus.Wait()
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: "drain"}],
+		"expected job to start only on the other job's maintenance: %v", got)
+}
+
+// A Job with rise/fall thresholds should publish status events only when
+// the thresholds are crossed, and not for every health check result.
+func TestJobHealthThresholds(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "myjob",
+		Health: &HealthConfig{
+			CheckExec: "true",
+			Heartbeat: 10,
+			TTL:       50,
+			Rise:      2,
+			Fall:      2,
+		},
+	}
+	cfg.Validate(noop)
+	job := NewJob(cfg)
+	job.Subscribe(bus)
+	job.Register(bus)
+	job.Run(context.Background(), stopCh)
+
+	passed := events.Event{Code: events.ExitSuccess, Source: "check.myjob"}
+	failed := events.Event{Code: events.ExitFailed, Source: "check.myjob"}
+	for _, event := range []events.Event{
+		passed, passed, passed, // healthy after 2nd pass
+		failed, passed, // blip below the 'fall' threshold
+		failed, failed, failed, // unhealthy after 2nd failure
+	} {
+		bus.Publish(event)
+	}
+	bus.Publish(events.QuitByTest)
+	bus.Wait()
+
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, 1, got[events.Event{Code: events.StatusHealthy, Source: "myjob"}])
+	assert.Equal(t, 1, got[events.Event{Code: events.StatusUnhealthy, Source: "myjob"}])
+	assert.Equal(t, statusUnhealthy, job.GetStatus())
+}
+
+// A Job whose health check exits with one of its warning exit codes
+// should be degraded, which is still available to dependents. By default
+// exit code 1 is a warning and 2 or higher is critical.
+func TestJobHealthWarning(t *testing.T) {
+	runHealthCheck := func(check string, warningCodes []int) (map[events.Event]int, JobStatus) {
+		bus := events.NewEventBus()
+		stopCh := make(chan struct{}, 1)
+		cfg := &Config{
+			Name: "myjob",
+			Health: &HealthConfig{
+				CheckExec:    check,
+				Heartbeat:    10,
+				TTL:          50,
+				WarningCodes: warningCodes,
+			},
+		}
+		cfg.Validate(noop)
+		job := NewJob(cfg)
+		job.Subscribe(bus)
+		job.Register(bus)
+		job.Run(context.Background(), stopCh)
+
+		job.healthCheck.Run(context.Background(), bus)
+		time.Sleep(100 * time.Millisecond)
+		bus.Publish(events.QuitByTest)
+		bus.Wait()
+
+		got := map[events.Event]int{}
+		for _, result := range bus.DebugEvents() {
+			got[result]++
+		}
+		return got, job.GetStatus()
+	}
+	healthy := events.Event{Code: events.StatusHealthy, Source: "myjob"}
+	unhealthy := events.Event{Code: events.StatusUnhealthy, Source: "myjob"}
+
+	got, status := runHealthCheck("false", nil) // exits 1
+	assert.Equal(t, 1, got[healthy])
+	assert.Equal(t, 0, got[unhealthy])
+	assert.Equal(t, statusDegraded, status)
+	assert.Equal(t, "degraded", status.String())
+
+	got, status = runHealthCheck("./testdata/test.sh failStuff", nil) // exits 255
+	assert.Equal(t, 0, got[healthy])
+	assert.Equal(t, 1, got[unhealthy])
+	assert.Equal(t, statusUnhealthy, status)
+
+	got, status = runHealthCheck("false", []int{3})
+	assert.Equal(t, 0, got[healthy], "warningExitCodes should replace the default")
+	assert.Equal(t, 1, got[unhealthy], "warningExitCodes should replace the default")
+	assert.Equal(t, statusUnhealthy, status)
+}
+
+// A degraded Job is healthy for the Jobs waiting on it, so with the
+// default warningExitCodes a health check exiting 1 starts its dependents
+func TestJobHealthWarningStartsDependents(t *testing.T) {
+	bus := events.NewEventBus()
+	dbCfg := &Config{
+		Name:   "db",
+		Health: &HealthConfig{CheckExec: "false", Heartbeat: 10, TTL: 50},
+	}
+	dbCfg.Validate(noop)
+	appCfg := &Config{
+		Name: "app",
+		Exec: "true",
+		When: &WhenConfig{Source: "db", Once: "healthy"},
+	}
+	appCfg.Validate(noop)
+	db := NewJob(dbCfg)
+	app := NewJob(appCfg)
+	for _, job := range []*Job{db, app} {
+		job.Subscribe(bus)
+		job.Register(bus)
+		job.Run(context.Background(), make(chan struct{}, 1))
+	}
+
+	db.healthCheck.Run(context.Background(), bus)
+	time.Sleep(100 * time.Millisecond)
+	bus.Publish(events.QuitByTest)
+	bus.Wait()
+
+	got := map[events.Event]int{}
+	for _, result := range bus.DebugEvents() {
+		got[result]++
+	}
+	assert.Equal(t, statusDegraded, db.GetStatus())
+	assert.Equal(t, 1, got[events.Event{Code: events.StatusHealthy, Source: "db"}])
+	assert.Equal(t, 1, got[events.Event{Code: events.ExitSuccess, Source: "app"}],
+		"expected app to start when db's health check exits 1")
+}
+
+// A Job should send the output of its health check exec to Consul
+func TestJobHealthCheckOutput(t *testing.T) {
+	bus := events.NewEventBus()
+	stopCh := make(chan struct{}, 1)
+	cfg := &Config{
+		Name: "myjob",
+		Health: &HealthConfig{
+			CheckExec: "./testdata/test.sh doStuff --debug",
+			Heartbeat: 10,
+			TTL:       50,
+		},
+	}
+	cfg.Validate(noop)
+	backend := &ttlRecorder{}
+	job := NewJob(cfg)
+	job.Service = &discovery.ServiceDefinition{ID: "myjob", Consul: backend}
+	job.Subscribe(bus)
+	job.Register(bus)
+	job.Run(context.Background(), stopCh)
+
+	job.healthCheck.Run(context.Background(), bus)
+	time.Sleep(100 * time.Millisecond)
+	bus.Publish(events.QuitByTest)
+	bus.Wait()
+
+	assert.Equal(t, "pass", backend.status)
+	assert.Equal(t, "Running doStuff with args: --debug\n", backend.output)
 }
 
 func TestJobProcessEvent(t *testing.T) {
@@ -281,6 +1127,7 @@ func TestJobProcessEvent(t *testing.T) {
 			startEvent:   events.Event{events.StatusChanged, "upstream"},
 			startsRemain: 1,
 			statusLock:   &sync.RWMutex{},
+			clock:        events.WallClock{},
 		}
 		got := job.processEvent(nil, events.Event{events.StatusChanged, "upstream"})
 		assert.Equal(t, jobContinue, got, "processEvent after 1st startEvent")
@@ -304,6 +1151,7 @@ func TestJobProcessEvent(t *testing.T) {
 			restartLimit:   2,
 			restartsRemain: 2,
 			statusLock:     &sync.RWMutex{},
+			clock:          events.WallClock{},
 		}
 		got := job.processEvent(nil, events.Event{events.StatusChanged, "upstream"})
 		assert.Equal(t, jobContinue, got, "processEvent after 1st startEvent")
@@ -342,6 +1190,7 @@ func TestJobProcessEvent(t *testing.T) {
 			restartLimit:   -1,
 			restartsRemain: -1,
 			statusLock:     &sync.RWMutex{},
+			clock:          events.WallClock{},
 		}
 		got := job.processEvent(nil, events.Event{events.StatusChanged, "upstream"})
 		assert.Equal(t, jobContinue, got, "processEvent after 1st startEvent")
@@ -372,6 +1221,7 @@ func TestJobProcessEvent(t *testing.T) {
 			startEvent:   events.Event{events.StatusChanged, "upstream"},
 			startsRemain: unlimited,
 			statusLock:   &sync.RWMutex{},
+			clock:        events.WallClock{},
 		}
 		got := job.processEvent(nil, events.Event{events.StatusChanged, "upstream"})
 		assert.Equal(t, jobContinue, got, "processEvent after 1st startEvent")
@@ -401,6 +1251,7 @@ func TestJobProcessEvent(t *testing.T) {
 			restartLimit:   unlimited,
 			restartsRemain: unlimited,
 			statusLock:     &sync.RWMutex{},
+			clock:          events.WallClock{},
 		}
 		got := job.processEvent(nil, events.GlobalStartup)
 		assert.Equal(t, statusUnknown, job.Status)
@@ -424,6 +1275,7 @@ func TestJobProcessEvent(t *testing.T) {
 			restartLimit:   1,
 			restartsRemain: 1,
 			statusLock:     &sync.RWMutex{},
+			clock:          events.WallClock{},
 		}
 		got := job.processEvent(nil, events.GlobalStartup)
 		assert.Equal(t, statusUnknown, job.Status)
@@ -450,6 +1302,7 @@ func TestJobProcessEvent(t *testing.T) {
 			startsRemain:   unlimited,
 			restartsRemain: unlimited,
 			statusLock:     &sync.RWMutex{},
+			clock:          events.WallClock{},
 		}
 		got := job.processEvent(nil, startEvent)
 		assert.Equal(t, jobContinue, got, "processEvent after 1st startEvent")
@@ -468,3 +1321,28 @@ func TestJobProcessEvent(t *testing.T) {
 	})
 
 }
+
+// ttlRecorder is a mock discovery.Backend that records the last TTL update
+type ttlRecorder struct {
+	mocks.NoopDiscoveryBackend
+	status string
+	output string
+}
+
+func (r *ttlRecorder) UpdateTTL(checkID, output, status string) error {
+	r.status = status
+	r.output = output
+	return nil
+}
+
+// maintenanceRecorder is a mock discovery.Backend that counts the times
+// maintenance mode is cleared
+type maintenanceRecorder struct {
+	mocks.NoopDiscoveryBackend
+	cleared int
+}
+
+func (r *maintenanceRecorder) DisableServiceMaintenance(serviceID string) error {
+	r.cleared++
+	return nil
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import "time"
+
+// maintenance is why a Job is in maintenance mode and how long it stays
+// there. A zero ttl (or until) means that maintenance mode doesn't expire.
+type maintenance struct {
+	reason string
+	ttl    time.Duration
+	until  time.Time
+}
+
+// RequestMaintenance sets the reason and TTL for the next time the Job
+// enters maintenance mode. The control plane calls this just before it
+// publishes the event that puts the Job into maintenance mode.
+func (job *Job) RequestMaintenance(reason string, ttl time.Duration) {
+	job.maintenanceLock.Lock()
+	defer job.maintenanceLock.Unlock()
+	job.maintenanceRequest = maintenance{reason: reason, ttl: ttl}
+}
+
+// GetMaintenance returns the reason the Job is in maintenance mode and
+// when its maintenance mode expires, or the zero time if it doesn't
+func (job *Job) GetMaintenance() (string, time.Time) {
+	job.maintenanceLock.RLock()
+	defer job.maintenanceLock.RUnlock()
+	return job.maintenance.reason, job.maintenance.until
+}
+
+// startMaintenance takes the requested reason and TTL (if any) as the
+// Job's current maintenance mode
+func (job *Job) startMaintenance() maintenance {
+	job.maintenanceLock.Lock()
+	defer job.maintenanceLock.Unlock()
+	current := job.maintenanceRequest
+	job.maintenanceRequest = maintenance{}
+	if current.ttl > 0 {
+		current.until = job.clock.Now().Add(current.ttl)
+	}
+	job.maintenance = current
+	return current
+}
+
+func (job *Job) clearMaintenance() {
+	job.maintenanceLock.Lock()
+	defer job.maintenanceLock.Unlock()
+	job.maintenance = maintenance{}
+}
+
+// maintenanceExpired returns true if the Job's maintenance mode has a TTL
+// and it has passed. A timer left over from an earlier maintenance mode
+// doesn't expire the current one.
+func (job *Job) maintenanceExpired() bool {
+	job.maintenanceLock.RLock()
+	defer job.maintenanceLock.RUnlock()
+	until := job.maintenance.until
+	return !until.IsZero() && !job.clock.Now().Before(until)
+}
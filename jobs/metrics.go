@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	overlapCollector   *prometheus.CounterVec
+	collapsedCollector *prometheus.CounterVec
+)
+
+func init() {
+	overlapCollector = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "containerpilot_job_overlapping_runs",
+		Help: "count of periodic job runs skipped or replaced because the previous run was still running, partitioned by job and action",
+	}, []string{"job", "action"})
+	collapsedCollector = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "containerpilot_job_collapsed_triggers",
+		Help: "count of job trigger events collapsed into an already pending start by debounce or throttle, partitioned by job and limit",
+	}, []string{"job", "limit"})
+	prometheus.MustRegister(overlapCollector, collapsedCollector)
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// cronSchedule is a cron expression evaluated in a specific timezone.
+// It implements events.Schedule.
+type cronSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// parseCron parses a standard 5-field cron expression, a 6-field cron
+// expression with a leading seconds field, or a descriptor like "@daily".
+// An empty timezone is treated as UTC.
+func parseCron(spec, timezone string) (*cronSchedule, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %v", timezone, err)
+	}
+	var schedule cron.Schedule
+	if len(strings.Fields(spec)) == 6 {
+		schedule, err = cron.Parse(spec)
+	} else {
+		schedule, err = cron.ParseStandard(spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cronSchedule{schedule: schedule, location: location}, nil
+}
+
+// Next implements events.Schedule
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t.In(s.location))
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	start := time.Date(2017, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	schedule, err := parseCron("30 2 * * *", "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2017, time.June, 2, 2, 30, 0, 0, time.UTC),
+			schedule.Next(start).UTC(), "5-field cron in UTC")
+	}
+
+	schedule, err = parseCron("15 30 2 * * *", "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2017, time.June, 2, 2, 30, 15, 0, time.UTC),
+			schedule.Next(start).UTC(), "6-field cron with seconds")
+	}
+
+	schedule, err = parseCron("30 2 * * *", "America/New_York")
+	if assert.NoError(t, err) {
+		// 02:30 EDT is 06:30 UTC
+		assert.Equal(t, time.Date(2017, time.June, 2, 6, 30, 0, 0, time.UTC),
+			schedule.Next(start).UTC(), "5-field cron with timezone")
+	}
+
+	schedule, err = parseCron("@hourly", "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2017, time.June, 1, 13, 0, 0, 0, time.UTC),
+			schedule.Next(start).UTC(), "descriptor")
+	}
+
+	_, err = parseCron("* * *", "")
+	assert.Error(t, err)
+	_, err = parseCron("* * * * *", "Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
@@ -12,6 +12,7 @@ const (
 	statusMaintenance
 	statusAlwaysHealthy
 	statusCompleted
+	statusDegraded // health check is warning but the service is still usable
 )
 
 func (i JobStatus) String() string {
@@ -27,8 +28,16 @@ func (i JobStatus) String() string {
 		return "healthy"
 	case 6:
 		return "completed"
+	case 7:
+		return "degraded"
 	default:
 		// both idle and unknown return unknown for purposes of serialization
 		return "unknown"
 	}
 }
+
+// isAvailable returns true if the status is one in which the Job's
+// service should be discoverable
+func isAvailable(status JobStatus) bool {
+	return status == statusHealthy || status == statusDegraded
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+// triggerLimiter collapses bursts of the events that start a Job. With a
+// debounce, the Job starts only once its start events have been quiet for
+// the debounce period. With a throttle, the Job starts at most once per
+// throttle window; a start event during the window defers the start to
+// the end of the window. Any further start events while a start is
+// deferred are collapsed into it.
+type triggerLimiter struct {
+	debounce time.Duration
+	throttle time.Duration
+
+	pending      bool         // a start is deferred until a timer expires
+	pendingEvent events.Event // the most recent event collapsed into it
+	quietUntil   time.Time
+	lastStart    time.Time
+}
+
+func newTriggerLimiter(cfg *Config) *triggerLimiter {
+	if cfg.whenDebounce == 0 && cfg.whenThrottle == 0 {
+		return nil
+	}
+	return &triggerLimiter{
+		debounce: cfg.whenDebounce,
+		throttle: cfg.whenThrottle,
+	}
+}
+
+// trigger records a start event. It returns whether the Job should start
+// now and, if the start is deferred instead, how long to wait before
+// calling expired. If wait is zero and the Job shouldn't start now, the
+// event was collapsed into a start that was already deferred.
+func (l *triggerLimiter) trigger(event events.Event, now time.Time) (start bool, wait time.Duration) {
+	l.pendingEvent = event
+	if l.debounce > 0 {
+		l.quietUntil = now.Add(l.debounce)
+	}
+	if l.pending {
+		return false, 0
+	}
+	if l.debounce > 0 {
+		l.pending = true
+		return false, l.debounce
+	}
+	if wait := l.lastStart.Add(l.throttle).Sub(now); wait > 0 {
+		l.pending = true
+		return false, wait
+	}
+	l.lastStart = now
+	return true, 0
+}
+
+// expired is called when the timer for a deferred start expires. It
+// returns whether the Job should start now or, if the start needs to be
+// deferred further, how long to wait before calling expired again.
+func (l *triggerLimiter) expired(now time.Time) (start bool, wait time.Duration) {
+	if wait := l.quietUntil.Sub(now); wait > 0 {
+		return false, wait
+	}
+	if wait := l.lastStart.Add(l.throttle).Sub(now); l.throttle > 0 && wait > 0 {
+		return false, wait
+	}
+	l.pending = false
+	l.lastStart = now
+	return true, 0
+}
+
+// cancel drops a deferred start, so that it doesn't start the Job when
+// its timer expires
+func (l *triggerLimiter) cancel() {
+	l.pending = false
+	l.pendingEvent = events.NonEvent
+}
+
+// limit names the limit that collapses events, for logs and metrics
+func (l *triggerLimiter) limit() string {
+	if l.debounce > 0 {
+		return "debounce"
+	}
+	return "throttle"
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joyent/containerpilot/events"
+)
+
+func TestTriggerLimiterDebounce(t *testing.T) {
+	l := newTriggerLimiter(&Config{whenDebounce: time.Second})
+	changed := events.Event{Code: events.StatusChanged, Source: "watch.app"}
+	now := time.Now()
+
+	start, wait := l.trigger(changed, now)
+	assert.False(t, start)
+	assert.Equal(t, time.Second, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	start, wait = l.trigger(changed, now)
+	assert.False(t, start)
+	assert.Equal(t, time.Duration(0), wait, "collapsed into pending start")
+
+	now = now.Add(500 * time.Millisecond)
+	start, wait = l.expired(now)
+	assert.False(t, start, "not quiet yet")
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	start, _ = l.expired(now)
+	assert.True(t, start)
+	assert.Equal(t, changed, l.pendingEvent)
+}
+
+func TestTriggerLimiterThrottle(t *testing.T) {
+	l := newTriggerLimiter(&Config{whenThrottle: time.Second})
+	changed := events.Event{Code: events.StatusChanged, Source: "watch.app"}
+	now := time.Now()
+
+	start, _ := l.trigger(changed, now)
+	assert.True(t, start, "first event starts immediately")
+
+	now = now.Add(200 * time.Millisecond)
+	start, wait := l.trigger(changed, now)
+	assert.False(t, start)
+	assert.Equal(t, 800*time.Millisecond, wait, "deferred to end of window")
+
+	now = now.Add(200 * time.Millisecond)
+	start, wait = l.trigger(changed, now)
+	assert.False(t, start)
+	assert.Equal(t, time.Duration(0), wait, "collapsed into pending start")
+
+	now = now.Add(600 * time.Millisecond)
+	start, _ = l.expired(now)
+	assert.True(t, start)
+
+	now = now.Add(2 * time.Second)
+	start, _ = l.trigger(changed, now)
+	assert.True(t, start, "window has passed")
+}
+
+func TestTriggerLimiterCancel(t *testing.T) {
+	l := newTriggerLimiter(&Config{whenDebounce: time.Second})
+	changed := events.Event{Code: events.StatusChanged, Source: "watch.app"}
+	now := time.Now()
+
+	l.trigger(changed, now)
+	l.cancel()
+	assert.False(t, l.pending)
+	assert.Equal(t, events.NonEvent, l.pendingEvent)
+
+	start, wait := l.trigger(changed, now)
+	assert.False(t, start)
+	assert.Equal(t, time.Second, wait, "not collapsed into cancelled start")
+}
+
+func TestTriggerLimiterDisabled(t *testing.T) {
+	assert.Nil(t, newTriggerLimiter(&Config{}))
+}
@@ -0,0 +1,72 @@
+package simulate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/joyent/containerpilot/events"
+)
+
+// Backend is an in-memory discovery.Backend whose upstream services
+// change health on the schedule in a Scenario. Registrations and health
+// check updates from the jobs are accepted and discarded.
+type Backend struct {
+	scenario *Scenario
+	clock    events.Clock
+	start    time.Time
+	healthy  map[string]bool
+	lock     sync.Mutex
+}
+
+// NewBackend creates a Backend for the scenario, with the simulation
+// starting at the clock's current time
+func NewBackend(scenario *Scenario, clock events.Clock) *Backend {
+	return &Backend{
+		scenario: scenario,
+		clock:    clock,
+		start:    clock.Now(),
+		healthy:  map[string]bool{},
+	}
+}
+
+// CheckForUpstreamChanges returns whether the health of the service has
+// changed since it was last checked, and whether it's healthy now
+func (b *Backend) CheckForUpstreamChanges(service, tag, dc string) (didChange, isHealthy bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	isHealthy = b.scenario.healthy(service, b.clock.Now().Sub(b.start))
+	didChange = b.healthy[service] != isHealthy
+	b.healthy[service] = isHealthy
+	return didChange, isHealthy
+}
+
+// CheckRegister implements discovery.Backend
+func (b *Backend) CheckRegister(check *api.AgentCheckRegistration) error {
+	return nil
+}
+
+// UpdateTTL implements discovery.Backend
+func (b *Backend) UpdateTTL(checkID, output, status string) error {
+	return nil
+}
+
+// ServiceDeregister implements discovery.Backend
+func (b *Backend) ServiceDeregister(serviceID string) error {
+	return nil
+}
+
+// ServiceRegister implements discovery.Backend
+func (b *Backend) ServiceRegister(service *api.AgentServiceRegistration) error {
+	return nil
+}
+
+// EnableServiceMaintenance implements discovery.Backend
+func (b *Backend) EnableServiceMaintenance(serviceID, reason string) error {
+	return nil
+}
+
+// DisableServiceMaintenance implements discovery.Backend
+func (b *Backend) DisableServiceMaintenance(serviceID string) error {
+	return nil
+}
@@ -0,0 +1,157 @@
+// Package simulate provides the scripted inputs for simulating a
+// ContainerPilot configuration: the results of each job's exec and
+// health check, and the health of the services in Consul over time.
+package simulate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flynn/json5"
+	"github.com/joyent/containerpilot/commands"
+	"github.com/joyent/containerpilot/config/decode"
+	"github.com/joyent/containerpilot/config/timing"
+)
+
+const defaultDuration = 10 * time.Minute
+
+// Scenario is the script for a simulation
+type Scenario struct {
+	Duration time.Duration
+	runs     map[string][]commands.Stub
+	services map[string][]healthChange
+
+	counts map[string]int // number of runs so far of each exec
+	lock   sync.Mutex
+}
+
+type healthChange struct {
+	at      time.Duration
+	healthy bool
+}
+
+type rawScenario struct {
+	Duration string                 `mapstructure:"duration"`
+	Execs    map[string][]rawRun    `mapstructure:"execs"`
+	Services map[string][]rawHealth `mapstructure:"services"`
+}
+
+type rawRun struct {
+	ExitCode int    `mapstructure:"exitCode"`
+	Duration string `mapstructure:"duration"`
+	Output   string `mapstructure:"output"`
+}
+
+type rawHealth struct {
+	At      string `mapstructure:"at"`
+	Healthy bool   `mapstructure:"healthy"`
+}
+
+// LoadScenario reads and parses the JSON5 scenario file
+func LoadScenario(scenarioPath string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(scenarioPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read scenario file: %v", err)
+	}
+	return NewScenario(data)
+}
+
+// NewScenario parses the JSON5 scenario data
+func NewScenario(data []byte) (*Scenario, error) {
+	var raw map[string]interface{}
+	if err := json5.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse scenario: %v", err)
+	}
+	var rs rawScenario
+	if err := decode.ToStruct(raw, &rs); err != nil {
+		return nil, fmt.Errorf("scenario configuration error: %v", err)
+	}
+
+	scenario := &Scenario{
+		Duration: defaultDuration,
+		runs:     map[string][]commands.Stub{},
+		services: map[string][]healthChange{},
+		counts:   map[string]int{},
+	}
+	if rs.Duration != "" {
+		duration, err := timing.ParseDuration(rs.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse scenario duration: %v", err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("scenario duration must be positive: %s", rs.Duration)
+		}
+		scenario.Duration = duration
+	}
+	for name, runs := range rs.Execs {
+		for i, run := range runs {
+			stub := commands.Stub{ExitCode: run.ExitCode, Output: run.Output}
+			switch run.Duration {
+			case "":
+			case "forever":
+				stub.Duration = commands.Forever
+			default:
+				duration, err := timing.ParseDuration(run.Duration)
+				if err != nil || duration < 0 {
+					return nil, fmt.Errorf(
+						"unable to parse scenario execs[%s][%d].duration '%s'",
+						name, i, run.Duration)
+				}
+				stub.Duration = duration
+			}
+			scenario.runs[name] = append(scenario.runs[name], stub)
+		}
+	}
+	for name, changes := range rs.Services {
+		for i, change := range changes {
+			at, err := timing.ParseDuration(change.At)
+			if err != nil || at < 0 {
+				return nil, fmt.Errorf(
+					"unable to parse scenario services[%s][%d].at '%s'",
+					name, i, change.At)
+			}
+			scenario.services[name] = append(scenario.services[name],
+				healthChange{at: at, healthy: change.Healthy})
+		}
+		sort.SliceStable(scenario.services[name], func(i, j int) bool {
+			return scenario.services[name][i].at < scenario.services[name][j].at
+		})
+	}
+	return scenario, nil
+}
+
+// Stub returns the simulated result of the next run of the named job
+// exec or health check ("check.<job>"). The Nth run uses the Nth entry
+// in the scenario and the last entry repeats; anything not in the
+// scenario exits successfully right away.
+func (scenario *Scenario) Stub(name string) commands.Stub {
+	scenario.lock.Lock()
+	defer scenario.lock.Unlock()
+	runs := scenario.runs[name]
+	if len(runs) == 0 {
+		return commands.Stub{}
+	}
+	i := scenario.counts[name]
+	scenario.counts[name]++
+	if i >= len(runs) {
+		i = len(runs) - 1
+	}
+	return runs[i]
+}
+
+// healthy returns whether the service is healthy at the given offset from
+// the start of the simulation. Services are unhealthy until the scenario
+// says otherwise.
+func (scenario *Scenario) healthy(service string, at time.Duration) bool {
+	healthy := false
+	for _, change := range scenario.services[service] {
+		if change.at > at {
+			break
+		}
+		healthy = change.healthy
+	}
+	return healthy
+}
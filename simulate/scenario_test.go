@@ -0,0 +1,77 @@
+package simulate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joyent/containerpilot/commands"
+	"github.com/joyent/containerpilot/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScenarioStub(t *testing.T) {
+	scenario, err := NewScenario([]byte(`{
+	execs: {
+		app: [{exitCode: 1, duration: "10s", output: "oops"}, {duration: "forever"}]
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, defaultDuration, scenario.Duration)
+	assert.Equal(t, commands.Stub{ExitCode: 1, Duration: 10 * time.Second,
+		Output: "oops"}, scenario.Stub("app"), "first run")
+	assert.Equal(t, commands.Stub{Duration: commands.Forever},
+		scenario.Stub("app"), "second run")
+	assert.Equal(t, commands.Stub{Duration: commands.Forever},
+		scenario.Stub("app"), "last run repeats")
+	assert.Equal(t, commands.Stub{}, scenario.Stub("other"), "unlisted exec")
+}
+
+func TestScenarioBackend(t *testing.T) {
+	scenario, err := NewScenario([]byte(`{
+	duration: "2m",
+	services: {
+		db: [{at: "1m", healthy: false}, {at: 30, healthy: true}]
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, 2*time.Minute, scenario.Duration)
+	assert.False(t, scenario.healthy("db", 0))
+	assert.True(t, scenario.healthy("db", 30*time.Second))
+	assert.False(t, scenario.healthy("db", time.Minute))
+	assert.False(t, scenario.healthy("other", time.Minute))
+
+	start := time.Now()
+	clock := events.NewVirtualClock(start)
+	backend := NewBackend(scenario, clock)
+	didChange, isHealthy := backend.CheckForUpstreamChanges("db", "", "")
+	assert.False(t, didChange, "initially unhealthy service isn't a change")
+	assert.False(t, isHealthy)
+	clock.AdvanceTo(start.Add(30 * time.Second))
+	didChange, isHealthy = backend.CheckForUpstreamChanges("db", "", "")
+	assert.True(t, didChange, "healthy at 30s on the clock")
+	assert.True(t, isHealthy)
+}
+
+func TestScenarioErrors(t *testing.T) {
+	tests := []struct{ name, scenario, err string }{
+		{"bad json", `{`, "unable to parse scenario: "},
+		{"unknown key", `{foo: 1}`, "scenario configuration error: "},
+		{"bad duration", `{duration: "x"}`, "unable to parse scenario duration: "},
+		{"zero duration", `{duration: 0}`, "scenario duration must be positive: 0"},
+		{"bad exec duration", `{execs: {app: [{duration: "x"}]}}`,
+			"unable to parse scenario execs[app][0].duration 'x'"},
+		{"bad service at", `{services: {db: [{at: "x"}]}}`,
+			"unable to parse scenario services[db][0].at 'x'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewScenario([]byte(test.scenario))
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
@@ -5,9 +5,11 @@ package subcommands
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/joyent/containerpilot/client"
 	"github.com/joyent/containerpilot/config"
+	"github.com/joyent/containerpilot/jobs"
 )
 
 // Params ...
@@ -18,6 +20,13 @@ type Params struct {
 	ConfigPath      string
 	RenderFlag      string
 	MaintenanceFlag string
+	GraphFlag       string
+	SimulateFlag    string
+	ValidateFlag    string
+	JobName         string
+	JobAction       string
+	Reason          string
+	TTL             string
 
 	Metrics map[string]string
 	Env     map[string]string
@@ -38,20 +47,105 @@ func RenderHandler(params Params) error {
 	return config.RenderConfig(params.ConfigPath, params.RenderFlag)
 }
 
-// ReloadHandler fires a Reload request through the HTTPClient.
+// GraphHandler loads the configuration and prints the trigger graph of
+// its jobs and watches, in either Graphviz DOT or JSON format
+func GraphHandler(params Params) error {
+	if params.GraphFlag != "dot" && params.GraphFlag != "json" {
+		return fmt.Errorf("-graph: format must be 'dot' or 'json', got '%s'",
+			params.GraphFlag)
+	}
+	cfg, err := config.LoadConfig(params.ConfigPath)
+	if err != nil {
+		return err
+	}
+	watchNames := []string{}
+	for _, watch := range cfg.Watches {
+		watchNames = append(watchNames, watch.Name)
+	}
+	graph := jobs.NewGraph(cfg.Jobs, watchNames)
+	if params.GraphFlag == "dot" {
+		fmt.Print(graph.DOT())
+		return nil
+	}
+	graphJSON, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(graphJSON))
+	return nil
+}
+
+// SchemaHandler prints the JSON Schema of the configuration file
+func SchemaHandler(params Params) error {
+	schemaJSON, err := json.MarshalIndent(config.NewSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(schemaJSON))
+	return nil
+}
+
+// ValidateHandler renders and validates the configuration and prints
+// every error found, as text or as JSON. It returns an error if the
+// configuration isn't valid, so that ContainerPilot exits non-zero.
+func ValidateHandler(params Params) error {
+	if params.ValidateFlag != "text" && params.ValidateFlag != "json" {
+		return fmt.Errorf("-validate: format must be 'text' or 'json', got '%s'",
+			params.ValidateFlag)
+	}
+	errs, err := config.ValidateConfig(params.ConfigPath)
+	if err != nil {
+		if params.ValidateFlag != "json" {
+			return err
+		}
+		// the configuration couldn't be read or rendered, but CI still
+		// needs JSON to parse
+		errs = []*config.ValidationError{{Message: err.Error()}}
+	}
+	if params.ValidateFlag == "json" {
+		if errs == nil {
+			errs = []*config.ValidationError{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(errs); err != nil {
+			return err
+		}
+	} else {
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("-validate: found %d error(s) in the configuration", len(errs))
+	}
+	if params.ValidateFlag == "text" {
+		fmt.Println("ok")
+	}
+	return nil
+}
+
+// ReloadHandler fires a Reload request through the HTTPClient and prints
+// the differences in the configuration that was reloaded.
 func ReloadHandler(params Params) error {
 	client, err := initClient(params.ConfigPath)
 	if err != nil {
 		return err
 	}
-	if err := client.Reload(); err != nil {
+	diff, err := client.Reload()
+	if err != nil {
 		return fmt.Errorf("-reload: failed to run subcommand: %v", err)
 	}
+	if diff != "" {
+		fmt.Println(diff)
+	}
 	return nil
 }
 
 // MaintenanceHandler fires either an enable or disable SetMaintenance
-// request through the HTTPClient.
+// request through the HTTPClient, or a SetJobMaintenance request if a job
+// was named.
 func MaintenanceHandler(params Params) error {
 	client, err := initClient(params.ConfigPath)
 	if err != nil {
@@ -61,12 +155,40 @@ func MaintenanceHandler(params Params) error {
 	if params.MaintenanceFlag == "enable" {
 		flag = true
 	}
-	if err := client.SetMaintenance(flag); err != nil {
+	if params.JobName != "" {
+		err = client.SetJobMaintenance(params.JobName, flag, params.Reason, params.TTL)
+	} else {
+		err = client.SetMaintenance(flag, params.Reason, params.TTL)
+	}
+	if err != nil {
 		return fmt.Errorf("-maintenance: failed to run subcommand: %v", err)
 	}
 	return nil
 }
 
+// JobHandler fires a StartJob, StopJob, or RestartJob request for the
+// named job through the HTTPClient.
+func JobHandler(params Params) error {
+	client, err := initClient(params.ConfigPath)
+	if err != nil {
+		return err
+	}
+	switch params.JobAction {
+	case "start":
+		err = client.StartJob(params.JobName)
+	case "stop":
+		err = client.StopJob(params.JobName)
+	case "restart":
+		err = client.RestartJob(params.JobName)
+	default:
+		err = fmt.Errorf("unknown action '%s'", params.JobAction)
+	}
+	if err != nil {
+		return fmt.Errorf("-%s: failed to run subcommand: %v", params.JobAction, err)
+	}
+	return nil
+}
+
 // PutEnvHandler fires a PutEnv request through the HTTPClient.
 func PutEnvHandler(params Params) error {
 	client, err := initClient(params.ConfigPath)
@@ -101,9 +101,11 @@ func (metric *Metric) Run(pctx context.Context, bus *events.EventBus) {
 				default:
 					switch event {
 					case events.GlobalShutdown, events.QuitByTest:
+						metric.Handled()
 						return
 					}
 				}
+				metric.Handled()
 			case <-ctx.Done():
 				return
 			}
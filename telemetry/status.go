@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/joyent/containerpilot/jobs"
 	"github.com/joyent/containerpilot/watches"
@@ -18,18 +20,46 @@ type Status struct {
 	Jobs     []*jobStatusResponse
 	Services []*serviceStatusResponse
 	Watches  []string
+
+	lock sync.Mutex // jobs can be added or removed while serving
 }
 
 type jobStatusResponse struct {
-	Name   string
-	Status string
+	Name        string
+	Status      string
+	NextRun     string                     `json:",omitempty"` // only for jobs with a cron schedule
+	Maintenance *maintenanceStatusResponse `json:",omitempty"`
 }
 
 type serviceStatusResponse struct {
-	Name    string
-	Address string
-	Port    int
-	Status  string
+	Name        string
+	Address     string
+	Port        int
+	Status      string
+	Maintenance *maintenanceStatusResponse `json:",omitempty"`
+}
+
+// maintenanceStatusResponse is only reported for jobs in maintenance mode
+// that were given a reason or a TTL
+type maintenanceStatusResponse struct {
+	Reason    string `json:",omitempty"`
+	Remaining string `json:",omitempty"` // until maintenance mode expires
+}
+
+func newMaintenanceStatusResponse(job *jobs.Job) *maintenanceStatusResponse {
+	reason, until := job.GetMaintenance()
+	if reason == "" && until.IsZero() {
+		return nil
+	}
+	resp := &maintenanceStatusResponse{Reason: reason}
+	if !until.IsZero() {
+		remaining := until.Sub(time.Now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		resp.Remaining = remaining.Truncate(time.Second).String()
+	}
+	return resp
 }
 
 // StatusHandler implements http.Handler
@@ -50,16 +80,24 @@ func (sh StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, http.StatusText(failedStatus), failedStatus)
 		return
 	}
+	sh.telem.Status.lock.Lock()
+	defer sh.telem.Status.lock.Unlock()
 	for _, job := range sh.telem.Status.jobs {
 		status := fmt.Sprintf("%s", job.GetStatus())
+		maintenance := newMaintenanceStatusResponse(job)
 		for _, service := range sh.telem.Status.Services {
 			if service.Name == job.Name {
 				service.Status = status
+				service.Maintenance = maintenance
 			}
 		}
 		for _, jobStatus := range sh.telem.Status.Jobs {
 			if jobStatus.Name == job.Name {
 				jobStatus.Status = status
+				jobStatus.Maintenance = maintenance
+				if nextRun := job.NextRun(); !nextRun.IsZero() {
+					jobStatus.NextRun = nextRun.Format(time.RFC3339)
+				}
 			}
 		}
 	}
@@ -71,6 +109,8 @@ func (sh StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // MonitorJobs adds a list of Jobs for the /status handler to monitor
 func (t *Telemetry) MonitorJobs(jobs []*jobs.Job) {
 	if t != nil {
+		t.Status.lock.Lock()
+		defer t.Status.lock.Unlock()
 		for _, job := range jobs {
 			t.Status.jobs = append(t.Status.jobs, job)
 			if job.Service != nil && job.Service.Port != 0 {
@@ -92,15 +132,64 @@ func (t *Telemetry) MonitorJobs(jobs []*jobs.Job) {
 	}
 }
 
+// UnmonitorJob removes the named Job from the /status handler
+func (t *Telemetry) UnmonitorJob(name string) {
+	if t == nil {
+		return
+	}
+	t.Status.lock.Lock()
+	defer t.Status.lock.Unlock()
+	monitored := t.Status.jobs[:0]
+	for _, job := range t.Status.jobs {
+		if job.Name != name {
+			monitored = append(monitored, job)
+		}
+	}
+	t.Status.jobs = monitored
+	jobResponses := t.Status.Jobs[:0]
+	for _, jobResponse := range t.Status.Jobs {
+		if jobResponse.Name != name {
+			jobResponses = append(jobResponses, jobResponse)
+		}
+	}
+	t.Status.Jobs = jobResponses
+	serviceResponses := t.Status.Services[:0]
+	for _, serviceResponse := range t.Status.Services {
+		if serviceResponse.Name != name {
+			serviceResponses = append(serviceResponses, serviceResponse)
+		}
+	}
+	t.Status.Services = serviceResponses
+}
+
 // MonitorWatches adds a list of Watches for the /status handler to monitor
 func (t *Telemetry) MonitorWatches(watches []*watches.Watch) {
 
-	// these watch names are cached forever because they don't change
-	// unless we reload ContainerPilot itself (and this server)
+	// these watch names are cached because they only change when we
+	// reload, which adds or removes the watches that changed
 	if t != nil {
+		t.Status.lock.Lock()
+		defer t.Status.lock.Unlock()
 		for _, watch := range watches {
 			name := strings.TrimPrefix(watch.Name, "watch.")
 			t.Status.Watches = append(t.Status.Watches, name)
 		}
 	}
 }
+
+// UnmonitorWatch removes the named Watch from the /status handler
+func (t *Telemetry) UnmonitorWatch(name string) {
+	if t == nil {
+		return
+	}
+	t.Status.lock.Lock()
+	defer t.Status.lock.Unlock()
+	name = strings.TrimPrefix(name, "watch.")
+	monitored := t.Status.Watches[:0]
+	for _, watch := range t.Status.Watches {
+		if watch != name {
+			monitored = append(monitored, watch)
+		}
+	}
+	t.Status.Watches = monitored
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/joyent/containerpilot/events"
 	"github.com/joyent/containerpilot/jobs"
 	"github.com/joyent/containerpilot/tests"
 	"github.com/joyent/containerpilot/tests/mocks"
@@ -64,6 +66,14 @@ func TestStatusServerGet(t *testing.T) {
 					  interval: 1,
 					  ttl: 10
 					}
+				},
+				{
+					name: "myjob4",
+					exec: "true",
+					when: {
+					  cron: "30 2 * * *",
+					  timezone: "UTC"
+					}
 				}
 			]`),
 		noop)
@@ -72,6 +82,17 @@ func TestStatusServerGet(t *testing.T) {
 	}
 	jobs := jobs.FromConfigs(jobCfgs)
 
+	// put myjob1 into maintenance mode with a reason and TTL
+	bus := events.NewEventBus()
+	jobCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs[0].Subscribe(bus)
+	jobs[0].Register(bus)
+	jobs[0].Run(jobCtx, make(chan struct{}, 1))
+	jobs[0].RequestMaintenance("upgrading", time.Hour)
+	bus.Publish(events.Event{Code: events.EnterMaintenance, Source: "myjob1"})
+	time.Sleep(100 * time.Millisecond)
+
 	watchCfgs, err := watches.NewConfigs(
 		tests.DecodeRawToSlice(
 			`[{name: "watch1", interval: 1},
@@ -115,9 +136,21 @@ func TestStatusServerGet(t *testing.T) {
 	assert.Equal(t, 1, len(out.Services), "unexpected count of services")
 	assert.Equal(t, 80, out.Services[0].Port, "unexpected job port")
 	assert.Equal(t, "unknown", out.Services[0].Status, "unexpected job status")
-	assert.Equal(t, 2, len(out.Jobs), "unexpected count of services")
+	assert.Equal(t, 3, len(out.Jobs), "unexpected count of services")
 	assert.Equal(t, "myjob1", out.Jobs[0].Name)
-	assert.Equal(t, "unknown", out.Jobs[0].Status, "unexpected job status")
+	assert.Equal(t, "maintenance", out.Jobs[0].Status, "unexpected job status")
+	if assert.NotNil(t, out.Jobs[0].Maintenance, "expected maintenance details") {
+		assert.Equal(t, "upgrading", out.Jobs[0].Maintenance.Reason)
+		assert.Contains(t, []string{"1h0m0s", "59m59s"}, out.Jobs[0].Maintenance.Remaining)
+	}
+	assert.Nil(t, out.Jobs[1].Maintenance, "unexpected maintenance details")
 	assert.Equal(t, "myjob3", out.Jobs[1].Name)
 	assert.Equal(t, "unknown", out.Jobs[1].Status, "unexpected job status")
+	assert.Equal(t, "", out.Jobs[1].NextRun, "unexpected next run")
+	assert.Equal(t, "myjob4", out.Jobs[2].Name)
+	nextRun, err := time.Parse(time.RFC3339, out.Jobs[2].NextRun)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, nextRun.Hour(), "unexpected next run hour")
+		assert.Equal(t, 30, nextRun.Minute(), "unexpected next run minute")
+	}
 }
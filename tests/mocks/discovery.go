@@ -39,3 +39,13 @@ func (noop *NoopDiscoveryBackend) ServiceDeregister(serviceID string) error {
 func (noop *NoopDiscoveryBackend) ServiceRegister(service *api.AgentServiceRegistration) error {
 	return nil
 }
+
+// EnableServiceMaintenance (required for mock interface)
+func (noop *NoopDiscoveryBackend) EnableServiceMaintenance(serviceID, reason string) error {
+	return nil
+}
+
+// DisableServiceMaintenance (required for mock interface)
+func (noop *NoopDiscoveryBackend) DisableServiceMaintenance(serviceID string) error {
+	return nil
+}
@@ -2,9 +2,11 @@ package watches
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/joyent/containerpilot/config/decode"
 	"github.com/joyent/containerpilot/config/services"
+	"github.com/joyent/containerpilot/config/timing"
 	"github.com/joyent/containerpilot/discovery"
 )
 
@@ -13,9 +15,13 @@ type Config struct {
 	Name             string `mapstructure:"name"`
 	serviceName      string
 	Poll             int    `mapstructure:"interval"` // time in seconds
+	Splay            string `mapstructure:"splay"`
+	Jitter           string `mapstructure:"jitter"`
 	Tag              string `mapstructure:"tag"`
 	DC               string `mapstructure:"dc"` // Consul datacenter
 	discoveryService discovery.Backend
+	splay            time.Duration
+	jitter           time.Duration
 }
 
 // NewConfigs parses json config into a validated slice of Configs
@@ -47,6 +53,26 @@ func (cfg *Config) Validate(disc discovery.Backend) error {
 	if cfg.Poll < 1 {
 		return fmt.Errorf("watch[%s].interval must be > 0", cfg.serviceName)
 	}
+	splay, err := timing.GetTimeout(cfg.Splay)
+	if err != nil {
+		return fmt.Errorf("unable to parse watch[%s].splay '%s': %v",
+			cfg.serviceName, cfg.Splay, err)
+	}
+	if splay < 0 {
+		return fmt.Errorf("watch[%s].splay '%s' cannot be negative",
+			cfg.serviceName, cfg.Splay)
+	}
+	cfg.splay = splay
+	jitter, err := timing.GetTimeout(cfg.Jitter)
+	if err != nil {
+		return fmt.Errorf("unable to parse watch[%s].jitter '%s': %v",
+			cfg.serviceName, cfg.Jitter, err)
+	}
+	if jitter < 0 || jitter >= time.Duration(cfg.Poll)*time.Second {
+		return fmt.Errorf("watch[%s].jitter '%s' must be less than the interval",
+			cfg.serviceName, cfg.Jitter)
+	}
+	cfg.jitter = jitter
 	cfg.discoveryService = disc
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -28,6 +29,8 @@ func TestWatchesParse(t *testing.T) {
 	assert.Equal(watches[1].Name, "watch.upstreamB", "config for Name")
 	assert.Equal(watches[1].Poll, 79, "config for Poll")
 	assert.Equal(watches[1].DC, "us-east-1", "config for DC")
+	assert.Equal(watches[1].splay, 30*time.Second, "config for splay")
+	assert.Equal(watches[1].jitter, 5*time.Second, "config for jitter")
 }
 
 func TestWatchesConfigError(t *testing.T) {
@@ -38,4 +41,9 @@ func TestWatchesConfigError(t *testing.T) {
 	_, err = NewConfigs(tests.DecodeRawToSlice(
 		`[{"name": "myName"}]`), nil)
 	assert.Error(t, err, "watch[myName].interval must be > 0")
+
+	_, err = NewConfigs(tests.DecodeRawToSlice(
+		`[{"name": "myName", "interval": 10, "jitter": "10s"}]`), nil)
+	assert.Equal(t, "watch[myName].jitter '10s' must be less than the interval",
+		err.Error())
 }
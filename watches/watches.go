@@ -17,8 +17,14 @@ type Watch struct {
 	tag              string
 	dc               string
 	poll             int
+	splay            time.Duration
+	jitter           time.Duration
 	discoveryService discovery.Backend
-	rx               chan events.Event
+	clock            events.Clock
+
+	// the Watch isn't subscribed to the EventBus, but counts the events
+	// it receives there while it's running, just as a Subscriber does
+	rx events.Subscriber
 
 	events.Publisher
 }
@@ -27,16 +33,25 @@ const eventBufferSize = 1000
 
 // NewWatch creates a Watch from a validated Config
 func NewWatch(cfg *Config) *Watch {
+	return NewSimulatedWatch(cfg, events.WallClock{})
+}
+
+// NewSimulatedWatch creates a Watch from a validated Config whose poll
+// timer runs on the clock
+func NewSimulatedWatch(cfg *Config, clock events.Clock) *Watch {
 	watch := &Watch{
 		Name:             cfg.Name,
 		serviceName:      cfg.serviceName,
 		tag:              cfg.Tag,
 		dc:               cfg.DC,
 		poll:             cfg.Poll,
+		splay:            cfg.splay,
+		jitter:           cfg.jitter,
 		discoveryService: cfg.discoveryService,
+		clock:            clock,
 	}
 	// watch.InitRx()
-	watch.rx = make(chan events.Event, eventBufferSize)
+	watch.rx.Rx = make(chan events.Event, eventBufferSize)
 	return watch
 }
 
@@ -64,11 +79,18 @@ func (watch *Watch) Tick() time.Duration {
 // Run executes the event loop for the Watch
 func (watch *Watch) Run(pctx context.Context, bus *events.EventBus) {
 	watch.Register(bus)
+	watch.rx.Bus = bus
 	ctx, cancel := context.WithCancel(pctx)
 	timerSource := watch.Name + ".poll"
+	quit := events.Event{Code: events.Quit, Source: watch.Name}
 
-	// TODO(justinwr@): this could be replaced by a simple Ticker
-	events.NewEventTimer(ctx, watch.rx, watch.Tick(), timerSource)
+	if watch.splay > 0 || watch.jitter > 0 {
+		events.NewJitteredEventTimer(ctx, watch.clock, watch, watch.Tick(),
+			events.RandomDuration(watch.splay), watch.jitter, timerSource)
+	} else {
+		// TODO(justinwr@): this could be replaced by a simple Ticker
+		events.NewEventTimer(ctx, watch.clock, watch, watch.Tick(), timerSource)
+	}
 
 	go func() {
 		defer func() {
@@ -78,8 +100,11 @@ func (watch *Watch) Run(pctx context.Context, bus *events.EventBus) {
 		}()
 		for {
 			select {
-			case event, ok := <-watch.rx:
-				if !ok || event == events.QuitByTest {
+			case event, ok := <-watch.rx.Rx:
+				if !ok || event == events.QuitByTest || event == quit {
+					if ok {
+						watch.rx.Handled()
+					}
 					return
 				}
 				if event == (events.Event{events.TimerExpired, timerSource}) {
@@ -95,6 +120,7 @@ func (watch *Watch) Run(pctx context.Context, bus *events.EventBus) {
 						}
 					}
 				}
+				watch.rx.Handled()
 			case <-ctx.Done():
 				return
 			}
@@ -102,9 +128,10 @@ func (watch *Watch) Run(pctx context.Context, bus *events.EventBus) {
 	}()
 }
 
-// Receive receives an event into the internal control channel.
+// Receive receives an event into the internal control channel. A Quit
+// event with the Watch's name as its source stops the Watch.
 func (watch *Watch) Receive(event events.Event) {
-	watch.rx <- event
+	watch.rx.Receive(event)
 }
 
 // String implements the stdlib fmt.Stringer interface for pretty-printing